@@ -0,0 +1,221 @@
+package aozora
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"zundafilter/charset"
+	"zundafilter/filters"
+)
+
+// ヘッダの記号の説明を囲む区切り線
+var separatorPattern = regexp.MustCompile(`^-{10,}\s*$`)
+
+// 本文の後の底本、入力、校正の情報
+var footerPattern = regexp.MustCompile(`^底本[：:]`)
+
+// ルビの開始位置(｜)、ルビ(《かんじ》)、入力者注(［＃…］、外字の※［＃…］)
+var notationPattern = regexp.MustCompile(`｜|《[^》\n]*》|※?［＃[^］\n]*］`)
+
+/*
+* 青空文庫形式のテキストを読み込んで本文のみを変換し、wへ書き込む
+* 文字コード(UTF-8、Shift_JIS、EUC-JP)は入力全体から判定し、同じ文字コードで書き込む
+* ルビ、入力者注は取り除いて変換し、変換後のテキストの対応する位置へ戻す
+* ヘッダ(タイトル、作者、記号の説明)、フッタ(底本の情報)は変換しない
+ */
+func Convert(ctx context.Context, r io.Reader, w io.Writer, convert filters.ConvertFunc) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	encoding := charset.Detect(data)
+	reader, _, err := charset.NewReader(bytes.NewReader(data), encoding)
+	if err != nil {
+		return err
+	}
+	if data, err = io.ReadAll(reader); err != nil {
+		return err
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	bodyStart, bodyEnd := bodyRange(lines)
+	var output bytes.Buffer
+	for i, line := range lines {
+		if i < bodyStart || i >= bodyEnd {
+			output.WriteString(line)
+			continue
+		}
+		content := strings.TrimRight(line, "\r\n")
+		converted, err := convertLine(ctx, content, convert)
+		if err != nil {
+			return &filters.LineError{Line: i + 1, Err: err}
+		}
+		output.WriteString(converted)
+		output.WriteString(line[len(content):])
+	}
+
+	writer, err := charset.NewWriter(w, encoding)
+	if err != nil {
+		return err
+	}
+	if _, err := output.WriteTo(writer); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+/*
+* 本文の行の範囲[start, end)
+* ヘッダは2つ目の区切り線まで(区切り線が無い場合はヘッダ無し)、フッタは「底本：」の行から
+ */
+func bodyRange(lines []string) (int, int) {
+	start := 0
+	separators := 0
+	for i, line := range lines {
+		if separatorPattern.MatchString(line) {
+			separators++
+			if separators == 2 {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := len(lines)
+	for i := start; i < len(lines); i++ {
+		if footerPattern.MatchString(lines[i]) {
+			end = i
+			break
+		}
+	}
+	return start, end
+}
+
+/*
+* ルビ、入力者注を取り除いたテキストでの位置
+ */
+type notation struct {
+	offset int
+	raw    string
+	ruby   *rubyBase // ルビ、ルビの開始位置の場合はルビを振る文字の範囲
+}
+
+type rubyBase struct {
+	start int
+	end   int
+}
+
+/*
+* 1行(1段落)の変換
+* ルビを振る文字が置換された場合は、読みが合わなくなるためルビを取り除く
+ */
+func convertLine(ctx context.Context, line string, convert filters.ConvertFunc) (string, error) {
+	var plain strings.Builder
+	notations := []notation{}
+	explicitStart := -1 // 対応するルビの無い｜のnotationsでの位置
+	boundary := 0       // ルビを振る文字の範囲を遡る境界
+	position := 0
+	for _, loc := range notationPattern.FindAllStringIndex(line, -1) {
+		plain.WriteString(line[position:loc[0]])
+		position = loc[1]
+		raw := line[loc[0]:loc[1]]
+		switch {
+		case raw == "｜":
+			explicitStart = len(notations)
+			notations = append(notations, notation{offset: plain.Len(), raw: raw})
+		case strings.HasPrefix(raw, "《"):
+			base := &rubyBase{end: plain.Len()}
+			if explicitStart >= 0 {
+				base.start = notations[explicitStart].offset
+				notations[explicitStart].ruby = base
+				explicitStart = -1
+			} else {
+				base.start = implicitRubyStart(plain.String(), boundary)
+			}
+			notations = append(notations, notation{offset: plain.Len(), raw: raw, ruby: base})
+		default:
+			notations = append(notations, notation{offset: plain.Len(), raw: raw})
+		}
+		boundary = plain.Len()
+	}
+	plain.WriteString(line[position:])
+	if strings.TrimSpace(plain.String()) == "" {
+		return line, nil
+	}
+
+	result, err := convert(ctx, plain.String())
+	if err != nil {
+		return "", err
+	}
+	gaps := []filters.Gap{}
+	for _, n := range notations {
+		if n.ruby != nil && rubyBaseReplaced(result, *n.ruby) {
+			continue
+		}
+		// ｜は挿入された文字の後ろ、ルビと入力者注は直前の文字に付ける
+		gaps = append(gaps, filters.Gap{Offset: n.offset, Text: n.raw, After: n.raw == "｜"})
+	}
+	return result.InsertGaps(gaps), nil
+}
+
+/*
+* ｜の無いルビを振る文字の開始位置
+* 《の直前の文字と同じ種類(漢字、ひらがな、カタカナ、英数字)の文字が続く範囲とする
+ */
+func implicitRubyStart(text string, boundary int) int {
+	end := len(text)
+	last, _ := utf8.DecodeLastRuneInString(text[boundary:end])
+	kind := characterKind(last)
+	start := end
+	for start > boundary {
+		r, size := utf8.DecodeLastRuneInString(text[boundary:start])
+		if kind == kindOther || characterKind(r) != kind {
+			break
+		}
+		start -= size
+	}
+	return start
+}
+
+const (
+	kindOther = iota
+	kindKanji
+	kindHiragana
+	kindKatakana
+	kindAlphanumeric
+)
+
+func characterKind(r rune) int {
+	switch {
+	case unicode.Is(unicode.Han, r) || strings.ContainsRune("々〆ヶ〇", r):
+		return kindKanji
+	case unicode.Is(unicode.Hiragana, r):
+		return kindHiragana
+	case unicode.Is(unicode.Katakana, r) || r == 'ー':
+		return kindKatakana
+	case unicode.IsLetter(r) || unicode.IsDigit(r):
+		return kindAlphanumeric
+	default:
+		return kindOther
+	}
+}
+
+/*
+* ルビを振る文字が置換されたか
+ */
+func rubyBaseReplaced(result filters.ConvertResult, base rubyBase) bool {
+	for _, edit := range result.Edits {
+		original := edit.Original
+		if original.Start < base.end && original.End > base.start {
+			return true
+		}
+		if original.Start == original.End && base.start < original.Start && original.Start < base.end {
+			return true
+		}
+	}
+	return false
+}
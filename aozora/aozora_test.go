@@ -0,0 +1,103 @@
+package aozora
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"zundafilter/charset"
+	"zundafilter/filters"
+	"zundafilter/filters/filterstest"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expect      string
+		expectError bool
+	}{
+		{
+			name:   "ルビ",
+			input:  "東京《とうきょう》です。｜ずんだ餅《もち》です。お茶｜漬《づけ》です\n",
+			expect: "東京《とうきょう》なのだ。｜ずんだ餅《もち》なのだ。お茶｜漬《づけ》なのだ\n",
+		},
+		{
+			name:   "置換されたルビの文字",
+			input:  "私《わたし》です。｜私達《わたしたち》です\n",
+			expect: "ぼくなのだ。ぼく達なのだ\n",
+		},
+		{
+			name:   "入力者注",
+			input:  "［＃ここから２字下げ］\r\n本当です［＃「本当」に傍点］。※［＃「土へん＋竒」、第3水準1-15-67］です\r\n",
+			expect: "［＃ここから２字下げ］\r\n本当なのだ［＃「本当」に傍点］。※［＃「土へん＋竒」、第3水準1-15-67］なのだ\r\n",
+		},
+		{
+			name: "ヘッダとフッタ",
+			input: "題です\n作者です\n\n-------------------------------------------------------\n【テキスト中に現れる記号について】\n" +
+				"《》：ルビです\n-------------------------------------------------------\n\n本文です\n\n底本：「本です」\n入力：です\n",
+			expect: "題です\n作者です\n\n-------------------------------------------------------\n【テキスト中に現れる記号について】\n" +
+				"《》：ルビです\n-------------------------------------------------------\n\n本文なのだ\n\n底本：「本です」\n入力：です\n",
+		},
+		{
+			name:        "変換エラー",
+			input:       "はい\n\nerror\n",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := Convert(context.Background(), strings.NewReader(testCase.input), &output, filterstest.ConvertText)
+			if (err != nil) != testCase.expectError {
+				t.Fatalf("Convert() error = %v, expect error %v", err, testCase.expectError)
+			}
+			if err != nil {
+				var lineError *filters.LineError
+				if !errors.As(err, &lineError) || lineError.Line != 3 {
+					t.Fatalf("Convert() error = %v, expect LineError at line 3", err)
+				}
+				return
+			}
+			if output.String() != testCase.expect {
+				t.Fatalf("Convert() = %q, expect %q", output.String(), testCase.expect)
+			}
+		})
+	}
+}
+
+func TestConvertShiftJis(t *testing.T) {
+	input, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("東京《とうきょう》です\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := Convert(context.Background(), bytes.NewReader(input), &output, filterstest.ConvertText); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(output.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != "東京《とうきょう》なのだ\r\n" {
+		t.Fatalf("Convert() = %q, expect Shift_JIS of 東京《とうきょう》なのだ", decoded)
+	}
+}
+
+func TestConvertDecodeError(t *testing.T) {
+	input, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte("東京です\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input = append(input, 'a', 0xfd)
+	err = Convert(context.Background(), bytes.NewReader(input), io.Discard, filterstest.ConvertText)
+	var decodeError *charset.DecodeError
+	if !errors.As(err, &decodeError) || decodeError.Offset != int64(len(input)-1) {
+		t.Fatalf("Convert() error = %v, expect DecodeError at byte offset %d", err, len(input)-1)
+	}
+}
@@ -0,0 +1,303 @@
+package charset
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+/*
+* 入出力の文字コード
+ */
+type Encoding string
+
+const (
+	EncodingAuto     Encoding = "auto" // 入力は先頭から判定する。出力は入力と同じ文字コードとする
+	EncodingUtf8     Encoding = "utf-8"
+	EncodingShiftJis Encoding = "shift_jis"
+	EncodingEucJp    Encoding = "euc-jp"
+)
+
+// 自動判定に使う最大のバイト数
+const detectBytes = 64 * 1024
+
+/*
+* 文字コード名の解析(大文字、小文字、別名を許容する)
+ */
+func ParseEncoding(name string) (Encoding, error) {
+	switch strings.ReplaceAll(strings.ToLower(name), "_", "-") {
+	case "", "auto":
+		return EncodingAuto, nil
+	case "utf-8", "utf8":
+		return EncodingUtf8, nil
+	case "shift-jis", "sjis", "cp932", "windows-31j", "ms932":
+		return EncodingShiftJis, nil
+	case "euc-jp", "eucjp":
+		return EncodingEucJp, nil
+	default:
+		return "", fmt.Errorf("unknown encoding: %s (available: %s, %s, %s, %s)", name, EncodingAuto, EncodingUtf8, EncodingShiftJis, EncodingEucJp)
+	}
+}
+
+/*
+* 変換できないバイト、文字
+* errors.Is(err, ErrUnconvertible)で判定する
+ */
+var ErrUnconvertible = errors.New("unconvertible bytes")
+
+/*
+* 入力を読み込めないバイト
+ */
+type DecodeError struct {
+	Encoding Encoding
+	Offset   int64 // 入力の先頭からのバイト位置
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("invalid %s bytes at byte offset %d", e.Encoding, e.Offset)
+}
+
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrUnconvertible
+}
+
+/*
+* 出力の文字コードで表せない文字
+ */
+type EncodeError struct {
+	Encoding Encoding
+	Offset   int64 // 変換後のテキスト(UTF-8)の先頭からのバイト位置
+	Rune     rune
+}
+
+func (e *EncodeError) Error() string {
+	return fmt.Sprintf("%q (U+%04X) at byte offset %d can not be encoded in %s", e.Rune, e.Rune, e.Offset, e.Encoding)
+}
+
+func (e *EncodeError) Is(target error) bool {
+	return target == ErrUnconvertible
+}
+
+func textEncoding(e Encoding) encoding.Encoding {
+	switch e {
+	case EncodingShiftJis:
+		return japanese.ShiftJIS
+	case EncodingEucJp:
+		return japanese.EUCJP
+	default:
+		return nil
+	}
+}
+
+/*
+* 文字コードの判定
+* UTF-8として妥当であればUTF-8、そうでなければShift_JIS、EUC-JPのうち読み込めて日本語の文字が多い方とする
+* dataの末尾で途切れた文字は無視する
+ */
+func Detect(data []byte) Encoding {
+	if utf8.Valid(trimIncompleteRune(data)) {
+		return EncodingUtf8
+	}
+	detected, bestScore := EncodingShiftJis, -1
+	for _, candidate := range []Encoding{EncodingShiftJis, EncodingEucJp} {
+		decoded := make([]byte, len(data)*3)
+		nDst, _, err := newDecoder(candidate).Transform(decoded, data, false)
+		if err != nil && err != transform.ErrShortSrc {
+			continue
+		}
+		if score := japaneseRunes(decoded[:nDst]); score > bestScore {
+			detected, bestScore = candidate, score
+		}
+	}
+	return detected
+}
+
+func trimIncompleteRune(data []byte) []byte {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+/*
+* ひらがな、全角カタカナ、漢字の数(誤った文字コードで読むと半角カタカナや記号が多くなる)
+ */
+func japaneseRunes(text []byte) int {
+	count := 0
+	for _, r := range string(text) {
+		if unicode.In(r, unicode.Hiragana, unicode.Han) || (unicode.Is(unicode.Katakana, r) && r < 0xFF00) {
+			count++
+		}
+	}
+	return count
+}
+
+/*
+* 文字コードを変換してUTF-8で読み込むReader
+* EncodingAutoの場合は最初の読み込みで得られた先頭のバイト(最大detectBytes)から判定する
+* パイプ等からの入力は続きを待たずに判定するため、先頭がASCIIのみの場合はUTF-8となる
+* return: Reader、入力の文字コード(判定結果)
+ */
+func NewReader(r io.Reader, e Encoding) (io.Reader, Encoding, error) {
+	if e == EncodingAuto {
+		buffered := bufio.NewReaderSize(r, detectBytes)
+		// Peek(1)は1回の読み込みで得られたバイトをバッファに残す
+		if _, err := buffered.Peek(1); err != nil && err != io.EOF {
+			return nil, "", err
+		}
+		data, _ := buffered.Peek(buffered.Buffered())
+		e, r = Detect(data), buffered
+	}
+	switch e {
+	case EncodingUtf8:
+		return transform.NewReader(r, &strictTransformer{transformer: encoding.UTF8Validator, encoding: e}), e, nil
+	case EncodingShiftJis, EncodingEucJp:
+		return transform.NewReader(r, newDecoder(e)), e, nil
+	default:
+		return nil, "", fmt.Errorf("unknown encoding: %s", e)
+	}
+}
+
+/*
+* UTF-8のテキストを文字コードを変換して書き込むWriter
+* 書き込み終えたらCloseすること(wはCloseしない)
+ */
+func NewWriter(w io.Writer, e Encoding) (io.WriteCloser, error) {
+	switch e {
+	case EncodingUtf8:
+		return nopWriteCloser{w}, nil
+	case EncodingShiftJis, EncodingEucJp:
+		return transform.NewWriter(w, &strictTransformer{transformer: textEncoding(e).NewEncoder(), encoding: e, encode: true}), nil
+	default:
+		return nil, fmt.Errorf("unknown output encoding: %s", e)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+/*
+* UTF-8の検証、UTF-8からの変換で、変換できないバイト、文字を置換せずにエラーとする変換
+* エラーには入力の先頭からのバイト位置を含める
+ */
+type strictTransformer struct {
+	transformer transform.Transformer
+	encoding    Encoding
+	encode      bool
+	offset      int64 // 変換済みの入力のバイト数
+}
+
+func (t *strictTransformer) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	nDst, nSrc, err := t.transformer.Transform(dst, src, atEOF)
+	if t.encode {
+		if err != nil && err != transform.ErrShortDst && err != transform.ErrShortSrc {
+			r, _ := utf8.DecodeRune(src[nSrc:])
+			return nDst, nSrc, &EncodeError{Encoding: t.encoding, Offset: t.offset + int64(nSrc), Rune: r}
+		}
+		t.offset += int64(nSrc)
+		return nDst, nSrc, err
+	}
+	if err == encoding.ErrInvalidUTF8 {
+		return nDst, nSrc, &DecodeError{Encoding: t.encoding, Offset: t.offset + int64(nSrc)}
+	}
+	t.offset += int64(nSrc)
+	return nDst, nSrc, err
+}
+
+func (t *strictTransformer) Reset() {
+	t.transformer.Reset()
+	t.offset = 0
+}
+
+/*
+* Shift_JIS、EUC-JPを読み込めないバイトでエラーとするデコーダ
+* x/textのデコーダは読み込めないバイトをU+FFFDに置き換えてエラーを返さないため、
+* 先頭のバイトから決まる1文字分のバイトずつデコードし、1文字分を消費しない、または対応する文字の無いバイトをエラーとする
+ */
+type strictDecoder struct {
+	decoder  transform.Transformer
+	encoding Encoding
+	offset   int64 // 変換済みの入力のバイト数
+}
+
+func newDecoder(e Encoding) *strictDecoder {
+	return &strictDecoder{decoder: textEncoding(e).NewDecoder(), encoding: e}
+}
+
+func (d *strictDecoder) Transform(dst, src []byte, atEOF bool) (nDst int, nSrc int, err error) {
+	defer func() {
+		d.offset += int64(nSrc)
+	}()
+	for nSrc < len(src) {
+		if c := src[nSrc]; c < utf8.RuneSelf {
+			if nDst >= len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			dst[nDst] = c
+			nDst++
+			nSrc++
+			continue
+		}
+		size := d.charSize(src[nSrc])
+		if nSrc+size > len(src) {
+			if !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			size = len(src) - nSrc
+		}
+		n, m, err := d.decoder.Transform(dst[nDst:], src[nSrc:nSrc+size], true)
+		if err == transform.ErrShortDst {
+			return nDst, nSrc, err
+		}
+		// Shift_JIS、EUC-JPはU+FFFDを表せないため、U+FFFDはデコーダが置き換えた文字
+		if r, _ := utf8.DecodeRune(dst[nDst : nDst+n]); err != nil || m != size || r == utf8.RuneError {
+			return nDst, nSrc, &DecodeError{Encoding: d.encoding, Offset: d.offset + int64(nSrc)}
+		}
+		nDst += n
+		nSrc += m
+	}
+	return nDst, nSrc, nil
+}
+
+/*
+* 先頭のバイトから決まる1文字のバイト数
+ */
+func (d *strictDecoder) charSize(lead byte) int {
+	switch d.encoding {
+	case EncodingShiftJis:
+		if (0x81 <= lead && lead <= 0x9f) || (0xe0 <= lead && lead <= 0xfc) {
+			return 2
+		}
+	case EncodingEucJp:
+		switch {
+		case lead == 0x8f:
+			return 3
+		case lead == 0x8e || (0xa1 <= lead && lead <= 0xfe):
+			return 2
+		}
+	}
+	return 1
+}
+
+func (d *strictDecoder) Reset() {
+	d.decoder.Reset()
+	d.offset = 0
+}
@@ -0,0 +1,215 @@
+package charset
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"golang.org/x/text/encoding/japanese"
+)
+
+const testText = "ずんだもちを食べるのだ。カタカナと漢字、ASCIIも含むのだ\n"
+
+func encodeTestText(t *testing.T, e Encoding) []byte {
+	t.Helper()
+	if e == EncodingUtf8 {
+		return []byte(testText)
+	}
+	encoded, err := textEncoding(e).NewEncoder().Bytes([]byte(testText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestNewReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding Encoding
+		input    Encoding
+		repeat   int
+	}{
+		{name: "UTF-8", encoding: EncodingUtf8, input: EncodingUtf8, repeat: 3},
+		{name: "Shift_JIS", encoding: EncodingShiftJis, input: EncodingShiftJis, repeat: 3},
+		{name: "EUC-JP", encoding: EncodingEucJp, input: EncodingEucJp, repeat: 3},
+		{name: "判定(UTF-8)", encoding: EncodingAuto, input: EncodingUtf8, repeat: 3},
+		{name: "判定(Shift_JIS)", encoding: EncodingAuto, input: EncodingShiftJis, repeat: 3},
+		{name: "判定(EUC-JP)", encoding: EncodingAuto, input: EncodingEucJp, repeat: 3},
+		{name: "Shift_JIS(変換の区切りをまたぐ)", encoding: EncodingShiftJis, input: EncodingShiftJis, repeat: 500},
+		{name: "EUC-JP(変換の区切りをまたぐ)", encoding: EncodingEucJp, input: EncodingEucJp, repeat: 500},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			input := bytes.Repeat(encodeTestText(t, testCase.input), testCase.repeat)
+			reader, detected, err := NewReader(iotest.HalfReader(bytes.NewReader(input)), testCase.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if detected != testCase.input {
+				t.Fatalf("NewReader() encoding = %s, expect %s", detected, testCase.input)
+			}
+			actual, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actual) != strings.Repeat(testText, testCase.repeat) {
+				t.Fatalf("NewReader() read %q, expect %q", actual, strings.Repeat(testText, testCase.repeat))
+			}
+		})
+	}
+}
+
+func TestNewReaderDecodeError(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding Encoding
+		input    []byte
+		offset   int64
+	}{
+		{name: "UTF-8", encoding: EncodingUtf8, input: []byte("ずんだ\xff"), offset: 9},
+		{name: "Shift_JIS", encoding: EncodingShiftJis, input: append(append([]byte{}, encodeTestText(t, EncodingShiftJis)...), 'a', 0x81, 0x20), offset: int64(len(encodeTestText(t, EncodingShiftJis))) + 1},
+		{name: "EUC-JP", encoding: EncodingEucJp, input: []byte{'a', 'b', 0xa4, 0xa2, 0xa4}, offset: 4},
+		{name: "Shift_JIS(対応する文字の無い2バイト)", encoding: EncodingShiftJis, input: []byte{0x82, 0xa0, 0x85, 0x40, 'a'}, offset: 2},
+		{name: "Shift_JIS(先頭にならないバイト)", encoding: EncodingShiftJis, input: []byte{'a', 0xfd, 'b'}, offset: 1},
+		{name: "EUC-JP(先頭にならないバイト)", encoding: EncodingEucJp, input: []byte{0xa4, 0xa2, 0xff}, offset: 2},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			reader, _, err := NewReader(bytes.NewReader(testCase.input), testCase.encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = io.ReadAll(reader)
+			var decodeError *DecodeError
+			if !errors.As(err, &decodeError) || !errors.Is(err, ErrUnconvertible) {
+				t.Fatalf("NewReader() error = %v, expect DecodeError", err)
+			}
+			if decodeError.Offset != testCase.offset {
+				t.Fatalf("DecodeError.Offset = %d, expect %d", decodeError.Offset, testCase.offset)
+			}
+		})
+	}
+}
+
+func TestNewReaderReplacementCharacter(t *testing.T) {
+	// U+FFFDを含む妥当なUTF-8
+	input := "こんにちは\uFFFDです。"
+	for _, encoding := range []Encoding{EncodingUtf8, EncodingAuto} {
+		t.Run(string(encoding), func(t *testing.T) {
+			reader, detected, err := NewReader(strings.NewReader(input), encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if detected != EncodingUtf8 {
+				t.Fatalf("NewReader() encoding = %s, expect %s", detected, EncodingUtf8)
+			}
+			actual, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("NewReader() error = %v", err)
+			}
+			if string(actual) != input {
+				t.Fatalf("NewReader() read %q, expect %q", actual, input)
+			}
+		})
+	}
+}
+
+func TestNewReaderPipe(t *testing.T) {
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+	go pipeWriter.Write([]byte("ずんだ\n"))
+
+	// 入力の終わりを待たずに判定する
+	type opened struct {
+		reader   io.Reader
+		encoding Encoding
+		err      error
+	}
+	done := make(chan opened, 1)
+	go func() {
+		reader, encoding, err := NewReader(pipeReader, EncodingAuto)
+		done <- opened{reader: reader, encoding: encoding, err: err}
+	}()
+	var result opened
+	select {
+	case result = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("NewReader() waits for the end of the input")
+	}
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+	if result.encoding != EncodingUtf8 {
+		t.Fatalf("NewReader() encoding = %s, expect %s", result.encoding, EncodingUtf8)
+	}
+	buffer := make([]byte, 64)
+	n, err := result.reader.Read(buffer)
+	if err != nil || string(buffer[:n]) != "ずんだ\n" {
+		t.Fatalf("Read() = %q, %v, expect %q", buffer[:n], err, "ずんだ\n")
+	}
+
+	go func() {
+		pipeWriter.Write([]byte("もちです\n"))
+		pipeWriter.Close()
+	}()
+	rest, err := io.ReadAll(result.reader)
+	if err != nil || string(rest) != "もちです\n" {
+		t.Fatalf("ReadAll() = %q, %v, expect %q", rest, err, "もちです\n")
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	var output bytes.Buffer
+	writer, err := NewWriter(&output, EncodingShiftJis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(writer, testText); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expect, _ := japanese.ShiftJIS.NewEncoder().Bytes([]byte(testText))
+	if !bytes.Equal(output.Bytes(), expect) {
+		t.Fatalf("NewWriter() wrote %q, expect %q", output.Bytes(), expect)
+	}
+
+	// Shift_JISで表せない文字
+	writer, _ = NewWriter(io.Discard, EncodingShiftJis)
+	_, err = io.WriteString(writer, "ずんだ🫛")
+	if err == nil {
+		err = writer.Close()
+	}
+	var encodeError *EncodeError
+	if !errors.As(err, &encodeError) || encodeError.Offset != 9 || encodeError.Rune != '🫛' {
+		t.Fatalf("NewWriter() error = %v, expect EncodeError at byte offset 9", err)
+	}
+}
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		expect      Encoding
+		expectError bool
+	}{
+		{name: "", expect: EncodingAuto},
+		{name: "UTF8", expect: EncodingUtf8},
+		{name: "Shift_JIS", expect: EncodingShiftJis},
+		{name: "cp932", expect: EncodingShiftJis},
+		{name: "EUC-JP", expect: EncodingEucJp},
+		{name: "iso-2022-jp", expectError: true},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := ParseEncoding(testCase.name)
+			if (err != nil) != testCase.expectError || actual != testCase.expect {
+				t.Fatalf("ParseEncoding(%q) = %s, %v, expect %s", testCase.name, actual, err, testCase.expect)
+			}
+		})
+	}
+}
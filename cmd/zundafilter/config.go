@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"zundafilter/log"
+	"zundafilter/resource"
+
+	"gopkg.in/yaml.v2"
+)
+
+/*
+* config.yamlの変換の設定(ロガーの設定と同じファイルに記述する)
+* ex)
+* filters: [pronoun, mood]
+* disabledRules: [mood.past]
+ */
+type convertConfig struct {
+	Filters       []string `yaml:"filters"`
+	DisabledRules []string `yaml:"disabledRules"`
+}
+
+/*
+* 変換の設定の読み込み
+* 設定ファイルが無い場合は空の設定とする
+ */
+func loadConvertConfig(configPath string) (convertConfig, error) {
+	var config convertConfig
+	path, err := resource.Resolve(resource.KindConfig, log.CONFIG_NAME, configPath, log.CONFIG_ENV)
+	if errors.Is(err, resource.ErrNotFound) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	configYaml, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := yaml.Unmarshal(configYaml, &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+/*
+* カンマ区切りの名前の一覧
+* ex) "honorific, mood" -> [honorific mood]
+ */
+func splitNames(value string) []string {
+	names := []string{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"zundafilter"
+	"zundafilter/log"
+)
+
+/*
+* 変換するファイル
+ */
+type convertJob struct {
+	Input  string
+	Output string
+}
+
+/*
+* ファイル、ディレクトリの一括変換
+* ex) zundafilter convert -r -o out/ in/
+ */
+func runConvert(args []string) error {
+	flagSet := flag.NewFlagSet("convert", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: zundafilter [options] convert [convert options] -o <output dir> <file or dir>...")
+		flagSet.PrintDefaults()
+	}
+	output := flagSet.String("o", "", "output directory (relative paths of the inputs are kept)")
+	recursive := flagSet.Bool("r", false, "convert files in the directories recursively")
+	workers := flagSet.Int("j", runtime.NumCPU(), "number of files converted at the same time")
+	force := flagSet.Bool("f", false, "convert even if the output is up to date")
+	// 他のオプションと同様に入力の後にも指定できるようにする
+	inputs := []string{}
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if flagSet.NArg() == 0 {
+			break
+		}
+		inputs = append(inputs, flagSet.Arg(0))
+		args = flagSet.Args()[1:]
+	}
+	if *output == "" || len(inputs) == 0 {
+		flagSet.Usage()
+		return errors.New("convert: need -o and at least one input")
+	}
+	if *workers < 1 {
+		return errors.New("convert: -j must be 1 or more")
+	}
+
+	jobs, err := listConvertJobs(inputs, *output, *recursive)
+	if err != nil {
+		return err
+	}
+
+	options, err := filterOptions()
+	if err != nil {
+		return err
+	}
+	stats := &zundafilter.Stats{}
+	options = append(options,
+		zundafilter.WithTokenizerPoolSize(*workers),
+		zundafilter.WithStats(stats),
+	)
+	filter, err := newFilter(options)
+	if err != nil {
+		return err
+	}
+	defer filter.Close()
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	summary := convertFiles(ctx, filter, jobs, *workers, *force)
+	printConvertSummary(os.Stderr, summary, stats)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("convert: %d files failed", summary.Failed)
+	}
+	return nil
+}
+
+/*
+* 入力から変換するファイルの一覧を作成する
+* ファイルは<出力先>/<ファイル名>、ディレクトリは<出力先>/<ディレクトリからの相対パス>へ出力する
+ */
+func listConvertJobs(inputs []string, output string, recursive bool) ([]convertJob, error) {
+	jobs := []convertJob{}
+	for _, input := range inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			jobs = append(jobs, convertJob{
+				Input:  input,
+				Output: filepath.Join(output, filepath.Base(input)),
+			})
+			continue
+		}
+		if !recursive {
+			return nil, fmt.Errorf("%s is a directory (use -r)", input)
+		}
+		err = filepath.WalkDir(input, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// 出力先が入力のディレクトリ内にある場合は出力を変換しない
+			if entry.IsDir() && sameFile(path, output) {
+				return fs.SkipDir
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(input, path)
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, convertJob{
+				Input:  path,
+				Output: filepath.Join(output, rel),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+func sameFile(path1 string, path2 string) bool {
+	info1, err := os.Stat(path1)
+	if err != nil {
+		return false
+	}
+	info2, err := os.Stat(path2)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(info1, info2)
+}
+
+type convertSummary struct {
+	Converted int
+	Skipped   int
+	Failed    int
+}
+
+/*
+* workers個のgoroutineで変換する
+* 失敗したファイルは標準エラーに出力して次のファイルを変換する
+ */
+func convertFiles(ctx context.Context, filter *zundafilter.Filter, jobs []convertJob, workers int, force bool) convertSummary {
+	var (
+		mutex   sync.Mutex
+		summary convertSummary
+		wg      sync.WaitGroup
+	)
+	jobCh := make(chan convertJob)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				skipped, err := convertFile(ctx, filter, job, force)
+				mutex.Lock()
+				switch {
+				case err != nil:
+					summary.Failed++
+					fmt.Fprintf(os.Stderr, "%s: %v\n", job.Input, err)
+				case skipped:
+					summary.Skipped++
+				default:
+					summary.Converted++
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		jobCh <- job
+	}
+	close(jobCh)
+	wg.Wait()
+	return summary
+}
+
+/*
+* 1ファイルの変換
+* 出力が入力より新しい場合は変換しない
+* 中断、失敗した場合に出力が更新済みと判定されないよう、一時ファイルに書き込んでから置き換える
+ */
+func convertFile(ctx context.Context, filter *zundafilter.Filter, job convertJob, force bool) (bool, error) {
+	inputInfo, err := os.Stat(job.Input)
+	if err != nil {
+		return false, err
+	}
+	if outputInfo, err := os.Stat(job.Output); err == nil && !force && !outputInfo.ModTime().Before(inputInfo.ModTime()) {
+		log.GetLogger().Sugar().Debugf("skip up to date file: %s", job.Output)
+		return true, nil
+	}
+
+	input, err := os.Open(job.Input)
+	if err != nil {
+		return false, err
+	}
+	defer input.Close()
+	if err := os.MkdirAll(filepath.Dir(job.Output), 0755); err != nil {
+		return false, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(job.Output), "."+filepath.Base(job.Output)+".*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = convertEncoding(input, tmp, func(r io.Reader, w io.Writer) error {
+		return convertFormat(ctx, filter, job.Input, r, w)
+	})
+	if err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmp.Name(), inputInfo.Mode().Perm()); err != nil {
+		return false, err
+	}
+	return false, os.Rename(tmp.Name(), job.Output)
+}
+
+func printConvertSummary(w io.Writer, summary convertSummary, stats *zundafilter.Stats) {
+	fmt.Fprintf(w, "files: %d converted, %d skipped, %d failed\n", summary.Converted, summary.Skipped, summary.Failed)
+	fmt.Fprintf(w, "sentences: %d\n", stats.Sentences())
+	ruleHits := stats.RuleHits()
+	names := make([]string, 0, len(ruleHits))
+	for name := range ruleHits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "rule hits:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s: %d\n", name, ruleHits[name])
+	}
+}
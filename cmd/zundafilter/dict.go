@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"zundafilter/log"
+	"zundafilter/zunda_mecab"
+)
+
+const dictUsage = `usage: zundafilter dict <command> [options]
+
+commands:
+  build    build zunda.db from a local IPADIC directory
+  migrate  upgrade zunda.db to the schema of this zundafilter
+  add      add or update a replacement
+  remove   remove a replacement
+  list     list replacements
+  import   add replacements from a csv file (source,target[,word type[,word|original_form]])`
+
+/*
+* 辞書(zunda.db)の管理コマンド
+ */
+func runDict(args []string) error {
+	if len(args) <= 0 {
+		return errors.New(dictUsage)
+	}
+	switch args[0] {
+	case "build":
+		return runDictBuild(args[1:])
+	case "migrate":
+		return runDictMigrate(args[1:])
+	case "add":
+		return runDictAdd(args[1:])
+	case "remove":
+		return runDictRemove(args[1:])
+	case "list":
+		return runDictList(args[1:])
+	case "import":
+		return runDictImport(args[1:])
+	default:
+		return fmt.Errorf("unknown dict command: %s\n%s", args[0], dictUsage)
+	}
+}
+
+func runDictBuild(args []string) error {
+	flagSet := flag.NewFlagSet("dict build", flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: zundafilter dict build [options] <ipadic dir>")
+		flagSet.PrintDefaults()
+	}
+	output := flagSet.String("o", "", "output path (default: the existing zunda.db or $XDG_DATA_HOME/zundafilter/zunda.db)")
+	encoding := flagSet.String("encoding", string(zunda_mecab.IpadicEncodingAuto), "IPADIC csv encoding (auto, euc-jp, utf-8)")
+	version := flagSet.String("version", "", "IPADIC version recorded in zunda.db (default: detected from configure.in or directory name)")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() != 1 {
+		flagSet.Usage()
+		return errors.New("dict build: need one IPADIC directory")
+	}
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = *dbPath
+	}
+	path, err := zunda_mecab.ZundaDbOutputPath(outputPath)
+	if err != nil {
+		return err
+	}
+	builder := zunda_mecab.ZundaDbBuilder{
+		Logger:    log.GetLogger(),
+		IpadicDir: flagSet.Arg(0),
+		Encoding:  zunda_mecab.IpadicEncoding(*encoding),
+		Version:   *version,
+	}
+	if err := builder.Build(path); err != nil {
+		return err
+	}
+	fmt.Printf("created %s\n", path)
+	return nil
+}
+
+func runDictMigrate(args []string) error {
+	flagSet, flags := newDictFlagSet("dict migrate", "usage: zundafilter dict migrate [options]", false)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	path, err := flags.path()
+	if err != nil {
+		return err
+	}
+	from, to, err := zunda_mecab.MigrateZundaDb(path, log.GetLogger())
+	if err != nil {
+		return err
+	}
+	if from == to {
+		fmt.Printf("%s is up to date (schema version %d)\n", path, to)
+		return nil
+	}
+	fmt.Printf("migrated %s from schema version %d to %d\n", path, from, to)
+	return nil
+}
+
+/*
+* dictコマンド共通のオプション
+ */
+type dictFlags struct {
+	db           *string
+	wordType     *string
+	originalForm *bool
+}
+
+func newDictFlagSet(name string, usage string, withKey bool) (*flag.FlagSet, dictFlags) {
+	flagSet := flag.NewFlagSet(name, flag.ContinueOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), usage)
+		flagSet.PrintDefaults()
+	}
+	flags := dictFlags{
+		db: flagSet.String("db", "", "zunda.db path (default: --db of zundafilter)"),
+	}
+	if withKey {
+		flags.wordType = flagSet.String("pos", "", "word type (名詞, 動詞, ...). empty matches any word type")
+		flags.originalForm = flagSet.Bool("base", false, "match the original form instead of the surface")
+	}
+	return flagSet, flags
+}
+
+func (f dictFlags) path() (string, error) {
+	if *f.db != "" {
+		return *f.db, nil
+	}
+	return zunda_mecab.ResolveZundaDbPath(*dbPath)
+}
+
+func (f dictFlags) openRepository(writable bool) (*zunda_mecab.ZundaDbRepository, error) {
+	path, err := f.path()
+	if err != nil {
+		return nil, err
+	}
+	if writable {
+		return zunda_mecab.NewWritableZundaDbRepository(path, log.GetLogger())
+	}
+	return zunda_mecab.NewZundaDbRepository(path, log.GetLogger())
+}
+
+func (f dictFlags) key() (zunda_mecab.ReplacementKey, zunda_mecab.MecabWordType, error) {
+	key := zunda_mecab.ReplacementKeyWord
+	if *f.originalForm {
+		key = zunda_mecab.ReplacementKeyOriginalForm
+	}
+	wordType, err := zunda_mecab.ParseReplacementWordType(*f.wordType)
+	return key, wordType, err
+}
+
+func runDictAdd(args []string) error {
+	flagSet, flags := newDictFlagSet("dict add", "usage: zundafilter dict add [options] <source> <target>", true)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() != 2 || flagSet.Arg(0) == "" {
+		flagSet.Usage()
+		return errors.New("dict add: need source and target")
+	}
+	key, wordType, err := flags.key()
+	if err != nil {
+		return err
+	}
+	repository, err := flags.openRepository(true)
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+	return repository.InsertReplacements([]zunda_mecab.ReplacementRow{
+		{
+			Source:   flagSet.Arg(0),
+			Key:      key,
+			WordType: wordType,
+			Target:   flagSet.Arg(1),
+		},
+	})
+}
+
+func runDictRemove(args []string) error {
+	flagSet, flags := newDictFlagSet("dict remove", "usage: zundafilter dict remove [options] <source>", true)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() != 1 {
+		flagSet.Usage()
+		return errors.New("dict remove: need source")
+	}
+	key, wordType, err := flags.key()
+	if err != nil {
+		return err
+	}
+	repository, err := flags.openRepository(true)
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+	return repository.DeleteReplacement(flagSet.Arg(0), key, wordType)
+}
+
+func runDictList(args []string) error {
+	flagSet, flags := newDictFlagSet("dict list", "usage: zundafilter dict list [options]", false)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	repository, err := flags.openRepository(false)
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+	replacements, err := repository.SelectReplacements()
+	if err != nil {
+		return err
+	}
+	// dict importでそのまま読み込める形式で出力する
+	writer := csv.NewWriter(os.Stdout)
+	for _, replacement := range replacements {
+		wordType := "*"
+		if replacement.WordType != zunda_mecab.MecabWordTypeUnknown {
+			wordType = replacement.WordType.String()
+		}
+		writer.Write([]string{replacement.Source, replacement.Target, wordType, replacement.Key.String()})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func runDictImport(args []string) error {
+	flagSet, flags := newDictFlagSet("dict import", "usage: zundafilter dict import [options] <csv file>", false)
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+	if flagSet.NArg() != 1 {
+		flagSet.Usage()
+		return errors.New("dict import: need one csv file")
+	}
+	file, err := os.Open(flagSet.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	replacements, err := zunda_mecab.ReadReplacementCsv(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", flagSet.Arg(0), err)
+	}
+	repository, err := flags.openRepository(true)
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+	if err := repository.InsertReplacements(replacements); err != nil {
+		return err
+	}
+	fmt.Printf("imported %d replacements\n", len(replacements))
+	return nil
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"zundafilter"
+)
+
+// --diffの出力形式
+const (
+	diffFormatAnsi    = "ansi"
+	diffFormatUnified = "unified"
+	diffFormatHtml    = "html"
+)
+
+// unified形式の変更行の前後に出力する行数
+const diffContextLines = 3
+
+/*
+* 変換前後の比較の出力
+* 変更箇所はフィルタが記録した置換範囲(Edit)を使う
+ */
+type diffWriter interface {
+	Write(result zundafilter.ConvertResult) error
+	Close() error
+}
+
+func newDiffWriter(format string, w io.Writer, name string) (diffWriter, error) {
+	switch format {
+	case diffFormatAnsi:
+		return &ansiDiffWriter{w: w}, nil
+	case diffFormatUnified:
+		return &unifiedDiffWriter{w: w, name: name}, nil
+	case diffFormatHtml:
+		return &htmlDiffWriter{w: w, name: name}, nil
+	default:
+		return nil, fmt.Errorf("unknown diff format: %s (available: %s, %s, %s)", format, diffFormatAnsi, diffFormatUnified, diffFormatHtml)
+	}
+}
+
+/*
+* 変換前後の文の置換範囲を装飾して連結する
+* originalがtrueの場合は変換前の文、falseの場合は変換後の文
+ */
+func decorateEdits(result zundafilter.ConvertResult, original bool, plain func(string) string, changed func(string, zundafilter.Edit) string) string {
+	text := result.Text
+	if original {
+		text = result.Original
+	}
+	var decorated strings.Builder
+	position := 0
+	for _, edit := range result.Edits {
+		span := edit.Replacement
+		if original {
+			span = edit.Original
+		}
+		decorated.WriteString(plain(text[position:span.Start]))
+		decorated.WriteString(changed(text[span.Start:span.End], edit))
+		position = span.End
+	}
+	decorated.WriteString(plain(text[position:]))
+	return decorated.String()
+}
+
+func ruleName(edit zundafilter.Edit) string {
+	return edit.Filter + "." + edit.Rule
+}
+
+/*
+* 端末向けの出力
+* 変換した文は変換前(-)の削除範囲を赤、変換後(+)の追加範囲を緑で表示する
+ */
+type ansiDiffWriter struct {
+	w io.Writer
+}
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+func (d *ansiDiffWriter) Write(result zundafilter.ConvertResult) error {
+	if strings.TrimSpace(result.Original) == "" {
+		return nil
+	}
+	if len(result.Edits) == 0 {
+		_, err := fmt.Fprintf(d.w, "  %s\n", strings.TrimRight(result.Original, "\n"))
+		return err
+	}
+	plain := func(text string) string {
+		return text
+	}
+	original := decorateEdits(result, true, plain, func(text string, edit zundafilter.Edit) string {
+		return ansiRed + text + ansiReset
+	})
+	converted := decorateEdits(result, false, plain, func(text string, edit zundafilter.Edit) string {
+		return ansiGreen + text + ansiReset
+	})
+	rules := []string{}
+	for _, edit := range result.Edits {
+		rules = append(rules, ruleName(edit))
+	}
+	_, err := fmt.Fprintf(d.w, "- %s\n+ %s\n  %s(%s)%s\n",
+		strings.TrimRight(original, "\n"),
+		strings.TrimRight(converted, "\n"),
+		ansiDim, strings.Join(rules, ", "), ansiReset)
+	return err
+}
+
+func (d *ansiDiffWriter) Close() error {
+	return nil
+}
+
+/*
+* unified diff形式の出力(行単位)
+* 改行は文末記号のため、変換前後の行は1対1に対応する
+ */
+type unifiedDiffWriter struct {
+	w         io.Writer
+	name      string
+	original  strings.Builder // 行の途中までの変換前の文
+	converted strings.Builder // 行の途中までの変換後の文
+	lines     []diffLine
+}
+
+type diffLine struct {
+	Original  string
+	Converted string
+}
+
+func (l diffLine) changed() bool {
+	return l.Original != l.Converted
+}
+
+func (d *unifiedDiffWriter) Write(result zundafilter.ConvertResult) error {
+	d.original.WriteString(result.Original)
+	d.converted.WriteString(result.Text)
+	if !strings.HasSuffix(result.Original, "\n") || !strings.HasSuffix(result.Text, "\n") {
+		return nil
+	}
+	originalLines := strings.SplitAfter(d.original.String(), "\n")
+	convertedLines := strings.SplitAfter(d.converted.String(), "\n")
+	if len(originalLines) != len(convertedLines) {
+		// 変換で改行が変わった場合は1行にまとめる
+		originalLines = []string{d.original.String()}
+		convertedLines = []string{d.converted.String()}
+	}
+	for i := range originalLines {
+		if originalLines[i] == "" && convertedLines[i] == "" {
+			continue
+		}
+		d.lines = append(d.lines, diffLine{Original: originalLines[i], Converted: convertedLines[i]})
+	}
+	d.original.Reset()
+	d.converted.Reset()
+	return nil
+}
+
+func (d *unifiedDiffWriter) Close() error {
+	if d.original.Len() > 0 || d.converted.Len() > 0 {
+		d.lines = append(d.lines, diffLine{Original: d.original.String(), Converted: d.converted.String()})
+	}
+	w := bufio.NewWriter(d.w)
+	headerWritten := false
+	for start := 0; start < len(d.lines); {
+		// 変更行の前後diffContextLines行を1つのhunkとし、間がdiffContextLines*2行以内のhunkはまとめる
+		first := start
+		for first < len(d.lines) && !d.lines[first].changed() {
+			first++
+		}
+		if first == len(d.lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(d.lines) && i <= last+diffContextLines*2; i++ {
+			if d.lines[i].changed() {
+				last = i
+			}
+		}
+		// 前後の文脈は入力の範囲内とする
+		hunkStart, hunkEnd := first-diffContextLines, last+diffContextLines+1
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		if hunkEnd > len(d.lines) {
+			hunkEnd = len(d.lines)
+		}
+		if !headerWritten {
+			fmt.Fprintf(w, "--- a/%s\n+++ b/%s\n", d.name, d.name)
+			headerWritten = true
+		}
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", hunkStart+1, hunkEnd-hunkStart, hunkStart+1, hunkEnd-hunkStart)
+		for i := hunkStart; i < hunkEnd; {
+			if !d.lines[i].changed() {
+				writeUnifiedLine(w, " ", d.lines[i].Original)
+				i++
+				continue
+			}
+			// 連続する変更行は削除行、追加行の順にまとめる
+			runEnd := i
+			for runEnd < hunkEnd && d.lines[runEnd].changed() {
+				runEnd++
+			}
+			for _, line := range d.lines[i:runEnd] {
+				writeUnifiedLine(w, "-", line.Original)
+			}
+			for _, line := range d.lines[i:runEnd] {
+				writeUnifiedLine(w, "+", line.Converted)
+			}
+			i = runEnd
+		}
+		start = hunkEnd
+	}
+	return w.Flush()
+}
+
+func writeUnifiedLine(w io.Writer, prefix string, line string) {
+	if strings.HasSuffix(line, "\n") {
+		fmt.Fprint(w, prefix, line)
+		return
+	}
+	fmt.Fprint(w, prefix, line, "\n\\ No newline at end of file\n")
+}
+
+/*
+* HTMLのレポート(変換前後を左右に並べる)
+* 置換範囲にはtitle属性で規則名を付ける
+ */
+type htmlDiffWriter struct {
+	w             io.Writer
+	name          string
+	headerWritten bool
+}
+
+const htmlDiffHeader = `<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+table { border-collapse: collapse; width: 100%%; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; vertical-align: top; white-space: pre-wrap; width: 50%%; }
+tr.unchanged td { color: #666; }
+del { background: #fdd; text-decoration: none; }
+ins { background: #dfd; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>%s</h1>
+<table>
+<tr><th>original</th><th>converted</th></tr>
+`
+
+const htmlDiffFooter = `</table>
+</body>
+</html>
+`
+
+func (d *htmlDiffWriter) writeHeader() error {
+	if d.headerWritten {
+		return nil
+	}
+	d.headerWritten = true
+	name := html.EscapeString(d.name)
+	_, err := fmt.Fprintf(d.w, htmlDiffHeader, name, name)
+	return err
+}
+
+func (d *htmlDiffWriter) Write(result zundafilter.ConvertResult) error {
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(result.Original) == "" {
+		return nil
+	}
+	class := "changed"
+	if len(result.Edits) == 0 {
+		class = "unchanged"
+	}
+	original := decorateEdits(result, true, html.EscapeString, func(text string, edit zundafilter.Edit) string {
+		return fmt.Sprintf(`<del title="%s">%s</del>`, html.EscapeString(ruleName(edit)), html.EscapeString(text))
+	})
+	converted := decorateEdits(result, false, html.EscapeString, func(text string, edit zundafilter.Edit) string {
+		return fmt.Sprintf(`<ins title="%s">%s</ins>`, html.EscapeString(ruleName(edit)), html.EscapeString(text))
+	})
+	_, err := fmt.Fprintf(d.w, "<tr class=\"%s\"><td>%s</td><td>%s</td></tr>\n",
+		class,
+		strings.TrimRight(original, "\n"),
+		strings.TrimRight(converted, "\n"))
+	return err
+}
+
+func (d *htmlDiffWriter) Close() error {
+	if err := d.writeHeader(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(d.w, htmlDiffFooter)
+	return err
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"zundafilter"
+)
+
+/*
+* 文の変換結果(fromを含む場合は最初のfromをtoに置換する)
+ */
+func diffTestResult(original string, from string, to string) zundafilter.ConvertResult {
+	result := zundafilter.ConvertResult{Original: original, Text: original, Edits: []zundafilter.Edit{}}
+	index := strings.Index(original, from)
+	if from == "" || index < 0 {
+		return result
+	}
+	result.Text = original[:index] + to + original[index+len(from):]
+	result.Edits = append(result.Edits, zundafilter.Edit{
+		Filter:      zundafilter.FilterMood,
+		Rule:        "affirmative",
+		Original:    zundafilter.Span{Start: index, End: index + len(from)},
+		Replacement: zundafilter.Span{Start: index, End: index + len(to)},
+	})
+	return result
+}
+
+/*
+* 1行ずつの変換結果(lineの番号の行の「です」を「なのだ」に置換する)
+ */
+func diffTestLines(count int, changed ...int) []zundafilter.ConvertResult {
+	results := []zundafilter.ConvertResult{}
+	for line := 1; line <= count; line++ {
+		from := ""
+		for _, number := range changed {
+			if number == line {
+				from = "です"
+			}
+		}
+		results = append(results, diffTestResult(fmt.Sprintf("%dです\n", line), from, "なのだ"))
+	}
+	return results
+}
+
+func TestAnsiDiffWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []zundafilter.ConvertResult
+		expect  string
+	}{
+		{
+			name: "変換した文と変換しない文",
+			results: []zundafilter.ConvertResult{
+				diffTestResult("ずんだです。", "です", "なのだ"),
+				diffTestResult("はい。\n", "", ""),
+				diffTestResult("\n", "", ""),
+			},
+			expect: "- ずんだ\x1b[31mです\x1b[0m。\n" +
+				"+ ずんだ\x1b[32mなのだ\x1b[0m。\n" +
+				"  \x1b[2m(mood.affirmative)\x1b[0m\n" +
+				"  はい。\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer, err := newDiffWriter(diffFormatAnsi, &output, "input.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, result := range testCase.results {
+				if err := writer.Write(result); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != testCase.expect {
+				t.Fatalf("ansiDiffWriter = %q, expect %q", output.String(), testCase.expect)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []zundafilter.ConvertResult
+		expect  string
+	}{
+		{
+			name:    "変更なし",
+			results: diffTestLines(3),
+			expect:  "",
+		},
+		{
+			name:    "離れた変更行",
+			results: diffTestLines(10, 2, 9),
+			expect: "--- a/input.txt\n+++ b/input.txt\n" +
+				"@@ -1,5 +1,5 @@\n 1です\n-2です\n+2なのだ\n 3です\n 4です\n 5です\n" +
+				"@@ -6,5 +6,5 @@\n 6です\n 7です\n 8です\n-9です\n+9なのだ\n 10です\n",
+		},
+		{
+			name:    "まとめる変更行",
+			results: diffTestLines(12, 4, 5, 11),
+			expect: "--- a/input.txt\n+++ b/input.txt\n" +
+				"@@ -1,12 +1,12 @@\n 1です\n 2です\n 3です\n-4です\n-5です\n+4なのだ\n+5なのだ\n" +
+				" 6です\n 7です\n 8です\n 9です\n 10です\n-11です\n+11なのだ\n 12です\n",
+		},
+		{
+			name: "行の途中の文と末尾の改行なし",
+			results: []zundafilter.ConvertResult{
+				diffTestResult("はい。", "", ""),
+				diffTestResult("そうです。\n", "です", "なのだ"),
+				diffTestResult("ずんだです", "です", "なのだ"),
+			},
+			expect: "--- a/input.txt\n+++ b/input.txt\n" +
+				"@@ -1,2 +1,2 @@\n-はい。そうです。\n-ずんだです\n\\ No newline at end of file\n" +
+				"+はい。そうなのだ。\n+ずんだなのだ\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer, err := newDiffWriter(diffFormatUnified, &output, "input.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, result := range testCase.results {
+				if err := writer.Write(result); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != testCase.expect {
+				t.Fatalf("unifiedDiffWriter = %q, expect %q", output.String(), testCase.expect)
+			}
+		})
+	}
+}
+
+func TestHtmlDiffWriter(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []zundafilter.ConvertResult
+		expect  string
+	}{
+		{
+			name:    "入力なし",
+			results: []zundafilter.ConvertResult{},
+			expect:  fmt.Sprintf(htmlDiffHeader, "a&lt;b&gt;.txt", "a&lt;b&gt;.txt") + htmlDiffFooter,
+		},
+		{
+			name: "エスケープ",
+			results: []zundafilter.ConvertResult{
+				diffTestResult("<b>&です\n", "です", "なのだ"),
+				diffTestResult("\n", "", ""),
+				diffTestResult("\"はい\"\n", "", ""),
+			},
+			expect: fmt.Sprintf(htmlDiffHeader, "a&lt;b&gt;.txt", "a&lt;b&gt;.txt") +
+				"<tr class=\"changed\"><td>&lt;b&gt;&amp;<del title=\"mood.affirmative\">です</del></td><td>&lt;b&gt;&amp;<ins title=\"mood.affirmative\">なのだ</ins></td></tr>\n" +
+				"<tr class=\"unchanged\"><td>&#34;はい&#34;</td><td>&#34;はい&#34;</td></tr>\n" +
+				htmlDiffFooter,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			writer, err := newDiffWriter(diffFormatHtml, &output, "a<b>.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, result := range testCase.results {
+				if err := writer.Write(result); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != testCase.expect {
+				t.Fatalf("htmlDiffWriter = %q, expect %q", output.String(), testCase.expect)
+			}
+		})
+	}
+}
+
+func TestNewDiffWriterUnknownFormat(t *testing.T) {
+	if _, err := newDiffWriter("side-by-side", &bytes.Buffer{}, "input.txt"); err == nil {
+		t.Fatal("newDiffWriter() error = nil, expect unknown diff format")
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io"
+	"zundafilter/charset"
+)
+
+/*
+* --input-encoding、--output-encodingの文字コードで読み書きする
+* convertにはUTF-8で読み書きするReader、Writerを渡す
+ */
+func convertEncoding(r io.Reader, w io.Writer, convert func(io.Reader, io.Writer) error) error {
+	inputEncoding, err := charset.ParseEncoding(*inputEnc)
+	if err != nil {
+		return err
+	}
+	outputEncoding, err := charset.ParseEncoding(*outputEnc)
+	if err != nil {
+		return err
+	}
+	reader, detected, err := charset.NewReader(r, inputEncoding)
+	if err != nil {
+		return err
+	}
+	if outputEncoding == charset.EncodingAuto {
+		outputEncoding = detected
+	}
+	writer, err := charset.NewWriter(w, outputEncoding)
+	if err != nil {
+		return err
+	}
+	err = convert(reader, writer)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"zundafilter"
+	"zundafilter/aozora"
+	"zundafilter/markdown"
+	"zundafilter/markup"
+	"zundafilter/subtitle"
+)
+
+// --formatの形式(字幕の形式はsubtitle.Format)
+const (
+	formatAuto     = "auto"
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatHtml     = "html"
+	formatXml      = "xml"
+	formatAozora   = "aozora" // 青空文庫(拡張子は.txtのため判定しない)
+)
+
+/*
+* 入力の形式に合わせた変換
+* --formatがautoの場合はファイル名の拡張子で判定し、判定できない場合はテキストとして変換する
+ */
+func convertFormat(ctx context.Context, filter *zundafilter.Filter, name string, r io.Reader, w io.Writer) error {
+	format := *formatName
+	if format == formatAuto {
+		format = formatFromPath(name)
+	}
+	switch format {
+	case formatText:
+		return filter.ConvertStream(ctx, r, w)
+	case formatMarkdown:
+		return markdown.Convert(ctx, r, w, filter.ConvertWithEdits)
+	case formatHtml, formatXml:
+		converter := markup.Converter{
+			Convert:      filter.ConvertWithEdits,
+			SkipElements: splitNames(*skipList),
+			XML:          format == formatXml,
+		}
+		return converter.Run(ctx, r, w)
+	case formatAozora:
+		return aozora.Convert(ctx, r, w, filter.ConvertWithEdits)
+	}
+	subtitleFormat, err := subtitle.ParseFormat(format)
+	if err != nil {
+		return fmt.Errorf("unknown format: %s (available: %s)", format, strings.Join(formatNames(), ", "))
+	}
+	return subtitle.Convert(ctx, subtitleFormat, r, w, filter.ConvertWithEdits)
+}
+
+func formatFromPath(path string) string {
+	if format, ok := subtitle.FormatFromPath(path); ok {
+		return string(format)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return formatMarkdown
+	case ".html", ".htm":
+		return formatHtml
+	case ".xml", ".xhtml", ".svg":
+		return formatXml
+	default:
+		return formatText
+	}
+}
+
+func formatNames() []string {
+	return []string{formatAuto, formatText, formatMarkdown, formatHtml, formatXml, formatAozora, string(subtitle.FormatSrt), string(subtitle.FormatVtt), string(subtitle.FormatAss)}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"zundafilter"
+	"zundafilter/jsonl"
+)
+
+/*
+* JSON Linesの変換(--jsonl)
+* 変換できなかったフィールド、読めない行は標準エラーに出力し、全てのレコードを出力した後でエラーとする
+ */
+func convertJsonl(ctx context.Context, filter *zundafilter.Filter, input io.Reader, w io.Writer) error {
+	if *jsonlJobs < 1 {
+		return errors.New("--jsonl-workers must be 1 or more")
+	}
+	converter := jsonl.Converter{
+		Convert:  filter.ConvertWithEdits,
+		Pointers: splitNames(*jsonlList),
+		MetaKey:  *jsonlMeta,
+		Workers:  *jsonlJobs,
+	}
+	failed := 0
+	err := converter.Run(ctx, input, w, func(recordError *jsonl.RecordError) {
+		failed++
+		fmt.Fprintln(os.Stderr, recordError)
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("jsonl: %d records or fields failed", failed)
+	}
+	return nil
+}
@@ -1,77 +1,252 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
+	"os/signal"
+	"runtime"
+	"strings"
 	"syscall"
-	"unsafe"
+	"zundafilter"
+	"zundafilter/charset"
 	"zundafilter/filters"
+	"zundafilter/jsonl"
 	"zundafilter/log"
+	"zundafilter/markup"
 	"zundafilter/zunda_mecab"
 
 	"golang.org/x/crypto/ssh/terminal"
 )
 
-func init() {
-	flag.Parse()
-}
+var (
+	configPath = flag.String("config", "", "logger config path (default: $ZUNDAFILTER_CONFIG, $XDG_CONFIG_HOME/zundafilter/config.yaml, data/config.yaml beside the executable)")
+	dbPath     = flag.String("db", "", "zunda.db path (default: $ZUNDAFILTER_DB, $XDG_DATA_HOME/zundafilter/zunda.db, data/zunda.db beside the executable)")
+	strict     = flag.Bool("strict", false, "fail on the first conversion error instead of leaving the sentence unconverted")
+	timeout    = flag.Duration("timeout", 0, "abort the conversion after this duration (ex: 30s). 0 means no timeout")
+	filterList = flag.String("filters", "", "comma separated filters in the order to apply (default: filters in config.yaml or "+strings.Join(filters.FilterNames(), ",")+")")
+	ruleList   = flag.String("disable-rules", "", "comma separated rules not to apply (ex: mood.past,honorific.specials)")
+	listRules  = flag.Bool("list-rules", false, "print the available filters and rules")
+	diffFormat = flag.String("diff", "", "print the original and converted sentences with the changed spans (ansi, unified, html)")
+	formatName = flag.String("format", formatAuto, "input format: auto (by the file extension), text, markdown, html, xml, aozora, srt, vtt, ass")
+	skipList   = flag.String("skip-elements", strings.Join(markup.DefaultSkipElements, ","), "comma separated elements not to convert in html and xml format")
+	inputEnc   = flag.String("input-encoding", string(charset.EncodingAuto), "input encoding: auto (detect from the head of the input), utf-8, shift_jis, euc-jp")
+	outputEnc  = flag.String("output-encoding", string(charset.EncodingAuto), "output encoding: auto (same as the input), utf-8, shift_jis, euc-jp")
+	jsonlMode  = flag.Bool("jsonl", false, "read one JSON object per line and convert the fields selected by --jsonl-fields")
+	jsonlList  = flag.String("jsonl-fields", jsonl.DEFAULT_POINTER, "comma separated JSON pointers of the fields to convert in --jsonl mode (ex: /text,/lines/0)")
+	jsonlMeta  = flag.String("jsonl-meta", "", "add the applied rules and errors to this field in --jsonl mode. empty means no metadata")
+	jsonlJobs  = flag.Int("jsonl-workers", runtime.NumCPU(), "number of records converted at the same time in --jsonl mode")
+)
 
 func main() {
-        logger := log.GetLogger()
+	// テストの実行時にテストのフラグを解析しないよう、initではなくmainで解析する
+	flag.Parse()
+	if err := log.Init(*configPath); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger := log.GetLogger()
 	defer logger.Sync()
 	sugar := logger.Sugar()
 
-	text, err := readFile()
+	if flag.Arg(0) == "dict" {
+		if err := runDict(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.Arg(0) == "convert" {
+		if err := runConvert(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *listRules {
+		for _, name := range filters.RuleNames() {
+			fmt.Println(name)
+		}
+		return
+	}
+
+	// 端末からの入力は1行ずつ変換する
+	if flag.NArg() == 0 && terminal.IsTerminal(int(syscall.Stdin)) {
+		if err := runRepl(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	input, err := openInput()
 	if err != nil {
 		sugar.Errorf("%v" , err)
 		os.Exit(1)
 	}
-	zundaDbRepository := zunda_mecab.ZundaDbRepository{}
-	mecabWrapper := zunda_mecab.MecabWrapper{
-		Logger:       log.GetLogger(),
+	options, err := filterOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	filter := filters.ZundaFilter{
-		ZundaDb:      zundaDbRepository,
-		MecabWrapper: &mecabWrapper,
-		Logger:       log.GetLogger(),
+	if *jsonlMode {
+		options = append(options, zundafilter.WithTokenizerPoolSize(*jsonlJobs))
 	}
-	convertedText, err := filter.Convert(text)
+	filter, err := newFilter(options)
 	if err != nil {
-		sugar.Errorf("ZundaFilter error: %v" , err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer filter.Close()
+	defer input.Close()
+
+	ctx, cancel := newContext()
+	defer cancel()
+	err = convertEncoding(input, os.Stdout, func(r io.Reader, w io.Writer) error {
+		// 1文ずつ変換して出力する(パイプの入力でも変換した文から順に出力される)
+		if *jsonlMode {
+			return convertJsonl(ctx, filter, r, w)
+		}
+		if *diffFormat != "" {
+			return convertDiff(ctx, filter, r, w)
+		}
+		return convertFormat(ctx, filter, inputName(), r, w)
+	})
+	var partialError *zundafilter.PartialError
+	if errors.As(err, &partialError) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err != nil {
+		// 設定によってはログが出力されないため標準エラーにも出力する
+		sugar.Errorf("ZundaFilter error: %v", err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	fmt.Print(convertedText)
 }
 
-func readFile() (string, error) {
+/*
+* 変換のオプション(コマンドラインの指定を設定ファイルより優先する)
+ */
+func filterOptions() ([]zundafilter.Option, error) {
+	config, err := currentConvertConfig()
+	if err != nil {
+		return nil, err
+	}
+	errorPolicy := zundafilter.ErrorPolicyLenient
+	if *strict {
+		errorPolicy = zundafilter.ErrorPolicyStrict
+	}
+	return []zundafilter.Option{
+		zundafilter.WithLogger(log.GetLogger()),
+		zundafilter.WithErrorPolicy(errorPolicy),
+		zundafilter.WithFilters(config.Filters...),
+		zundafilter.WithDisabledRules(config.DisabledRules...),
+		zundafilter.WithWarningHandler(func(warning error) {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", warning)
+		}),
+	}, nil
+}
+
+func currentConvertConfig() (convertConfig, error) {
+	config, err := loadConvertConfig(*configPath)
+	if err != nil {
+		return config, err
+	}
+	if names := splitNames(*filterList); len(names) > 0 {
+		config.Filters = names
+	}
+	if names := splitNames(*ruleList); len(names) > 0 {
+		config.DisabledRules = names
+	}
+	return config, nil
+}
+
+/*
+* Ctrl+C、--timeoutで中断するcontext
+ */
+func newContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		// 入力待ちで止まっている場合は2回目のCtrl+Cで終了できるようにする
+		<-ctx.Done()
+		stop()
+	}()
+	if *timeout <= 0 {
+		return ctx, stop
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, *timeout)
+	return timeoutCtx, func() {
+		cancel()
+		stop()
+	}
+}
+
+/*
+* zunda.dbを使うフィルタの作成
+* zunda.dbが無い、開けない場合は活用形を生成して変換する(単語リストの条件は合致しない)
+ */
+func newFilter(options []zundafilter.Option) (*zundafilter.Filter, error) {
+	sugar := log.GetLogger().Sugar()
+	path, err := zunda_mecab.ResolveZundaDbPath(*dbPath)
+	if err != nil {
+		sugar.Warnf("can not find zunda.db, use conjugator instead: %v", err)
+		return zundafilter.New(options...)
+	}
+	filter, err := zundafilter.New(append(options, zundafilter.WithDatabasePath(path))...)
+	explicit := *dbPath != "" || os.Getenv(zunda_mecab.DB_ENV) != ""
+	if errors.Is(err, zundafilter.ErrSchemaTooNew) || (err != nil && explicit) {
+		// 新しいバイナリで作成された辞書、明示的に指定された辞書は黙って無視しない
+		return nil, err
+	}
+	if err != nil {
+		sugar.Warnf("can not open %s, use conjugator instead: %v", path, err)
+		return zundafilter.New(options...)
+	}
+	return filter, nil
+}
+
+/*
+* 変換前後の比較を出力する
+ */
+func convertDiff(ctx context.Context, filter *zundafilter.Filter, input io.Reader, w io.Writer) error {
+	writer, err := newDiffWriter(*diffFormat, w, inputName())
+	if err != nil {
+		return err
+	}
+	err = filter.ConvertEach(ctx, input, writer.Write)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+/*
+* 入力のファイル名(標準入力の場合は"stdin")
+ */
+func inputName() string {
+	if flag.NArg() > 0 && flag.Arg(0) != "-" {
+		return flag.Arg(0)
+	}
+	return "stdin"
+}
+
+/*
+* 入力(引数のファイル、無い場合と"-"の場合は標準入力)
+ */
+func openInput() (io.ReadCloser, error) {
 	var filename string
 	if args := flag.Args(); len(args) > 0 {
 		filename = args[0]
 	}
-	var r io.Reader
 	switch filename {
-	case "":
-		if terminal.IsTerminal(int(syscall.Stdin)) {
-			return "", errors.New("usage: capitalize path")
-		}
-		r = os.Stdin
-	case "-":
-		r = os.Stdin
+	case "", "-":
+		return io.NopCloser(os.Stdin), nil
 	default:
-		f, err := os.Open(filename)
-		if err != nil {
-			return "", err
-		}
-		defer f.Close()
-		r = f
-	}
-	b, err := ioutil.ReadAll(r)
-	if err != nil {
-		return "", err
+		return os.Open(filename)
 	}
-	return *(*string)(unsafe.Pointer(&b)), nil
 }
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"zundafilter"
+	"zundafilter/filters"
+	"zundafilter/log"
+	"zundafilter/zunda_mecab"
+)
+
+const replUsage = `type a line to convert it, or a command:
+  :parse <text>        show the MeCab parse of the text
+  :explain <text>      convert the text and show the rules applied
+  :filters             show the filters and whether they are enabled
+  :toggle <filter>     enable or disable a filter (honorific, mood, pronoun)
+  :persona [<first>]   show or change the first person pronoun (ex: :persona おいら)
+  :help                show this help
+  :quit                exit`
+
+/*
+* 対話モード(標準入力が端末の場合)
+* 1行ずつ変換して出力する。変換の設定はコマンドで切り替える
+ */
+type repl struct {
+	out          io.Writer
+	order        []string        // フィルタの適用順
+	enabled      map[string]bool // フィルタの有効、無効
+	persona      zundafilter.Persona
+	stats        *zundafilter.Stats
+	filter       *zundafilter.Filter
+	mecabWrapper *zunda_mecab.MecabWrapper
+}
+
+func runRepl(in io.Reader, out io.Writer) error {
+	config, err := currentConvertConfig()
+	if err != nil {
+		return err
+	}
+	r := &repl{
+		out:          out,
+		enabled:      map[string]bool{},
+		persona:      filters.DefaultPersona,
+		stats:        &zundafilter.Stats{},
+		mecabWrapper: &zunda_mecab.MecabWrapper{Logger: log.GetLogger()},
+	}
+	// 無効なフィルタも切り替えられるよう、適用順の後ろに残りのフィルタを並べる
+	r.order = append(r.order, config.Filters...)
+	if len(r.order) == 0 {
+		r.order = filters.FilterNames()
+	}
+	for _, name := range r.order {
+		r.enabled[name] = true
+	}
+	for _, name := range filters.FilterNames() {
+		if !r.enabled[name] {
+			r.order = append(r.order, name)
+		}
+	}
+	if err := r.reload(); err != nil {
+		return err
+	}
+	defer r.close()
+
+	fmt.Fprintln(out, replUsage)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, ":") {
+			r.convert(line, false)
+			continue
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case ":parse":
+			r.parse(arg)
+		case ":explain":
+			r.convert(arg, true)
+		case ":filters":
+			r.printFilters()
+		case ":toggle":
+			r.toggle(arg)
+		case ":persona":
+			r.changePersona(arg)
+		case ":help":
+			fmt.Fprintln(out, replUsage)
+		case ":quit", ":q":
+			return nil
+		default:
+			fmt.Fprintf(out, "unknown command: %s (:help for usage)\n", command)
+		}
+	}
+}
+
+/*
+* 現在の設定でフィルタを作り直す
+ */
+func (r *repl) reload() error {
+	options, err := filterOptions()
+	if err != nil {
+		return err
+	}
+	options = append(options,
+		zundafilter.WithFilters(r.enabledFilters()...),
+		zundafilter.WithPersona(r.persona),
+		zundafilter.WithStats(r.stats),
+	)
+	filter, err := newFilter(options)
+	if err != nil {
+		return err
+	}
+	if r.filter != nil {
+		r.filter.Close()
+	}
+	r.filter = filter
+	return nil
+}
+
+func (r *repl) close() {
+	if r.filter != nil {
+		r.filter.Close()
+	}
+	r.mecabWrapper.Close()
+}
+
+func (r *repl) enabledFilters() []string {
+	names := []string{}
+	for _, name := range r.order {
+		if r.enabled[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+/*
+* 変換
+* explainの場合は適用された規則も出力する
+ */
+func (r *repl) convert(text string, explain bool) {
+	// フィルタが空の場合は全て適用されるため、変換しない
+	if len(r.enabledFilters()) == 0 {
+		fmt.Fprintln(r.out, text)
+		return
+	}
+	before := r.stats.RuleHits()
+	convertedText, err := r.filter.Convert(context.Background(), text)
+	if err != nil {
+		fmt.Fprintf(r.out, "error: %v\n", err)
+		return
+	}
+	fmt.Fprintln(r.out, convertedText)
+	if !explain {
+		return
+	}
+
+	after := r.stats.RuleHits()
+	names := []string{}
+	for name, count := range after {
+		if count > before[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(r.out, "  (no rules applied)")
+		return
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(r.out, "  %s: %d\n", name, after[name]-before[name])
+	}
+}
+
+func (r *repl) parse(text string) {
+	features, err := r.mecabWrapper.ParseToNodeWithoutEos(text)
+	if err != nil {
+		fmt.Fprintf(r.out, "error: %v\n", err)
+		return
+	}
+	for _, feature := range features {
+		fmt.Fprintln(r.out, feature.String())
+	}
+}
+
+func (r *repl) printFilters() {
+	for _, name := range r.order {
+		state := "off"
+		if r.enabled[name] {
+			state = "on"
+		}
+		fmt.Fprintf(r.out, "  %s: %s\n", name, state)
+	}
+}
+
+func (r *repl) toggle(name string) {
+	if _, ok := filters.LookupFilter(name); !ok {
+		fmt.Fprintf(r.out, "unknown filter: %s (available: %s)\n", name, strings.Join(filters.FilterNames(), ", "))
+		return
+	}
+	r.enabled[name] = !r.enabled[name]
+	if err := r.reload(); err != nil {
+		r.enabled[name] = !r.enabled[name]
+		fmt.Fprintf(r.out, "error: %v\n", err)
+		return
+	}
+	r.printFilters()
+}
+
+func (r *repl) changePersona(firstPerson string) {
+	if firstPerson != "" {
+		previous := r.persona
+		r.persona = zundafilter.Persona{FirstPerson: firstPerson}
+		if err := r.reload(); err != nil {
+			r.persona = previous
+			fmt.Fprintf(r.out, "error: %v\n", err)
+			return
+		}
+	}
+	fmt.Fprintf(r.out, "  first person: %s\n", r.persona.FirstPerson)
+}
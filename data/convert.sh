@@ -1,9 +1,7 @@
 #!/bin/bash
 
 rm -f convert_result.txt
-while read line; do
-	echo ${line} | ../zundafilter >> ./convert_result.txt
-	echo "" >> ./convert_result.txt
-done < "source.txt"
+# 1文ずつ変換するため、行ごとに起動しなくてよい
+../zundafilter source.txt > ./convert_result.txt
 
 cat ./convert_result.txt
@@ -8,13 +8,15 @@ VERB_FILE="Verb.csv"
 ADJ_FILE="Adj.csv"
 IMPORT_TEMP_CSV="temp.csv"
 DB_NAME="zunda.db"
+MIGRATION_DIR="$(cd "$(dirname "$0")/../zunda_mecab/migrations" && pwd)"
 
 ##################################################
 # functions
 ##################################################
 function usage() {
   echo "Usage: ${PROGNAME} [OPTIONS]"
-  echo "  need sqlite3."
+  echo "  need sqlite3 and network access."
+  echo "  to build from a local IPADIC, use 'zundafilter dict build <ipadic dir>' instead."
   echo "Options:"
   echo "  -h, --help"
   echo "  -v, --version"
@@ -65,35 +67,29 @@ function createDatabase() {
 
 function createTables() {
   outputInfo "createTables()"
-  # 動詞の未然形変換テーブル
-  createVerbConvertConjugationTable
-}
-
-function createVerbConvertConjugationTable() {
-  SQL=`cat << EOF
-CREATE TABLE ConvertVerbConjugationTable(
-  base_word TEXT, 
-  mizen TEXT
-);
-EOF
-`
-  echo "${SQL}" | sqlite3 ${DB_NAME}
+  # zundafilterと同じスキーマ変更を順に適用し、適用済みのバージョンを記録する
+  for file in "${MIGRATION_DIR}"/*.sql ; do
+    version=$(basename "${file}" | sed 's/_.*//' | sed 's/^0*//')
+    sqlite3 ${DB_NAME} < "${file}"
+    echo "INSERT OR REPLACE INTO MetadataTable(key, value) VALUES('schema_version', '${version}');" | sqlite3 ${DB_NAME}
+  done
 }
 
 function importData() {
   outputInfo "importData()"
-  # 動詞の未然形変換テーブル
-  importVerbConvertConjugationTable
+  # 動詞・形容詞の活用テーブル
+  importConjugationTable
 }
 
-function importVerbConvertConjugationTable() {
+function importConjugationTable() {
   removeImportTempCsv
 
-  cat ${VERB_FILE} | \
-    grep ',未然形,' | \
-    awk -F, '{printf("%s,%s\n", $11, $1)}' >> ./${IMPORT_TEMP_CSV}
+  # 読みだけが異なる同一表記の行は1行にまとめる
+  cat ${VERB_FILE} ${ADJ_FILE} | \
+    awk -F, '$10 != "*" {printf("%s,%s,%s,%s,%s\n", $11, $5, $9, $10, $1)}' | \
+    awk '!seen[$0]++' >> ./${IMPORT_TEMP_CSV}
 
-  sqlite3 -separator , ${DB_NAME} ".import ./${IMPORT_TEMP_CSV} ConvertVerbConjugationTable"
+  sqlite3 -separator , ${DB_NAME} ".import ./${IMPORT_TEMP_CSV} ConjugationTable"
 }
 
 function removeImportTempCsv() {
@@ -131,11 +127,11 @@ do
       break
       ;;
     -*)
-      echo "${PROGNAME}: illegal option -- '$(echo $1 | sed 's/^-*//')'" 1>$2
+      echo "${PROGNAME}: illegal option -- '$(echo "$1" | sed 's/^-*//')'" 1>&2
       exit 1
       ;;
     *)
-      if [ [ ! -z "$1"] && [ ! "$1" =~ ^-+ ] ] ; then
+      if [[ -n "$1" && ! "$1" =~ ^-+ ]] ; then
         param+=( "$1" )
         shift 1
       fi
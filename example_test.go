@@ -0,0 +1,83 @@
+package zundafilter_test
+
+import (
+	"context"
+	"fmt"
+	"zundafilter"
+)
+
+func Example() {
+	// zunda.dbを指定しない場合は活用形を生成して変換する
+	filter, err := zundafilter.New()
+	if err != nil {
+		panic(err)
+	}
+	defer filter.Close()
+
+	text, err := filter.Convert(context.Background(), "この暮になってひどいよ、おれにとっちゃあ一時間が何万円にもつくときだからね")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(text)
+	// Output: この暮になってひどいよ、ぼくにとっちゃあ一時間が何万円にもつくときだからね
+}
+
+func ExampleWithFilters() {
+	// 敬語の変換のみ
+	filter, err := zundafilter.New(zundafilter.WithFilters(zundafilter.FilterHonorific))
+	if err != nil {
+		panic(err)
+	}
+	defer filter.Close()
+
+	text, err := filter.Convert(context.Background(), "結局渡しませんでした")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(text)
+	// Output: 結局渡さなかった
+}
+
+func ExampleWithPersona() {
+	filter, err := zundafilter.New(
+		zundafilter.WithFilters(zundafilter.FilterPronoun),
+		zundafilter.WithPersona(zundafilter.Persona{FirstPerson: "おいら"}),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer filter.Close()
+
+	text, err := filter.Convert(context.Background(), "この暮になってひどいよ、おれにとっちゃあ一時間が何万円にもつくときだからね")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(text)
+	// Output: この暮になってひどいよ、おいらにとっちゃあ一時間が何万円にもつくときだからね
+}
+
+func ExampleFilter_ConvertBatch() {
+	// 変換できなかった文はそのまま返し、警告を通知する(この例では全ての文を変換できるため通知しない)
+	filter, err := zundafilter.New(
+		zundafilter.WithFilters(zundafilter.FilterHonorific),
+		zundafilter.WithErrorPolicy(zundafilter.ErrorPolicyLenient),
+		zundafilter.WithWarningHandler(func(warning error) {
+			fmt.Println("warning:", warning)
+		}),
+	)
+	if err != nil {
+		panic(err)
+	}
+	defer filter.Close()
+
+	texts, err := filter.ConvertBatch(context.Background(), []string{"ここからです", "体調不良でした"})
+	if err != nil {
+		panic(err)
+	}
+	for _, text := range texts {
+		fmt.Println(text)
+	}
+	// Output:
+	// ここから
+	// 体調不良だった
+}
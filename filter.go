@@ -0,0 +1,337 @@
+package zundafilter
+
+import (
+	"context"
+	"io"
+	"zundafilter/charset"
+	"zundafilter/filters"
+	"zundafilter/zunda_mecab"
+
+	"go.uber.org/zap"
+)
+
+/*
+* 変換エラー時の扱い、変換エラーの種類、変換結果等(filtersパッケージの別名)
+ */
+type (
+	ErrorPolicy   = filters.ErrorPolicy
+	Persona       = filters.Persona
+	ConvertError  = filters.ConvertError
+	PartialError  = filters.PartialError
+	Stats         = filters.Stats
+	ConvertResult = filters.ConvertResult
+	Edit          = filters.Edit
+	Span          = filters.Span
+	Encoding      = charset.Encoding
+)
+
+const (
+	ErrorPolicyStrict  = filters.ErrorPolicyStrict
+	ErrorPolicyLenient = filters.ErrorPolicyLenient
+)
+
+const (
+	FilterHonorific = filters.FilterHonorific
+	FilterMood      = filters.FilterMood
+	FilterPronoun   = filters.FilterPronoun
+)
+
+const (
+	EncodingAuto     = charset.EncodingAuto
+	EncodingUtf8     = charset.EncodingUtf8
+	EncodingShiftJis = charset.EncodingShiftJis
+	EncodingEucJp    = charset.EncodingEucJp
+)
+
+var (
+	ErrTokenizerUnavailable = filters.ErrTokenizerUnavailable
+	ErrDictionaryMissing    = filters.ErrDictionaryMissing
+	ErrRuleFailed           = filters.ErrRuleFailed
+	ErrSchemaTooNew         = zunda_mecab.ErrSchemaTooNew
+	ErrUnconvertible        = charset.ErrUnconvertible
+)
+
+/*
+* テキストをずんだもんの口調へ変換する
+* 複数のgoroutineから同時に利用できる。利用後はCloseすること
+ */
+type Filter struct {
+	filter         *filters.ZundaFilter
+	repository     *zunda_mecab.ZundaDbRepository
+	warningHandler func(error)
+	inputEncoding  Encoding
+	outputEncoding Encoding
+}
+
+type config struct {
+	logger           *zap.Logger
+	databasePath     string
+	tokenizerOptions []string
+	poolSize         int
+	filters          []string
+	disabledRules    []string
+	stats            *Stats
+	persona          Persona
+	errorPolicy      ErrorPolicy
+	warningHandler   func(error)
+	inputEncoding    Encoding
+	outputEncoding   Encoding
+}
+
+type Option func(*config)
+
+/*
+* ログの出力先
+* 指定しない場合はログを出力しない
+ */
+func WithLogger(logger *zap.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+/*
+* zunda.dbのパス
+* 指定しない場合はzunda.dbを使わずに活用形を生成して変換する(単語リストの条件は合致しない)
+ */
+func WithDatabasePath(path string) Option {
+	return func(c *config) {
+		c.databasePath = path
+	}
+}
+
+/*
+* MeCabの起動オプション
+* ex) WithTokenizerOptions("-d", "/usr/lib/x86_64-linux-gnu/mecab/dic/ipadic")
+ */
+func WithTokenizerOptions(options ...string) Option {
+	return func(c *config) {
+		c.tokenizerOptions = options
+	}
+}
+
+/*
+* 再利用するMeCabの数
+* 複数のgoroutineから同時に変換する場合はgoroutineの数を指定する。指定しない場合は変換ごとにMeCabを起動する
+ */
+func WithTokenizerPoolSize(size int) Option {
+	return func(c *config) {
+		c.poolSize = size
+	}
+}
+
+/*
+* 適用するフィルタ(FilterHonorific, FilterMood, FilterPronoun)
+* 指定した順に適用する。指定しない場合は全て適用する
+ */
+func WithFilters(names ...string) Option {
+	return func(c *config) {
+		c.filters = names
+	}
+}
+
+/*
+* 適用しない変換規則(<フィルタ名>.<規則名>)
+* ex) WithDisabledRules("mood.past", "honorific.specials")
+ */
+func WithDisabledRules(names ...string) Option {
+	return func(c *config) {
+		c.disabledRules = names
+	}
+}
+
+/*
+* 文の数、規則の適用回数の集計先
+ */
+func WithStats(stats *Stats) Option {
+	return func(c *config) {
+		c.stats = stats
+	}
+}
+
+/*
+* 変換後の話者
+* 指定できるのは一人称(Persona.FirstPerson)のみ。指定しない場合は「ぼく」
+ */
+func WithPersona(persona Persona) Option {
+	return func(c *config) {
+		c.persona = persona
+	}
+}
+
+/*
+* 変換エラー時の扱い
+* 指定しない場合はErrorPolicyStrict
+ */
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(c *config) {
+		c.errorPolicy = policy
+	}
+}
+
+/*
+* ErrorPolicyLenientで変換しなかった文のエラーの通知先
+ */
+func WithWarningHandler(handler func(error)) Option {
+	return func(c *config) {
+		c.warningHandler = handler
+	}
+}
+
+/*
+* ConvertStream、ConvertEachの入力の文字コード
+* 指定しない場合はEncodingUtf8。EncodingAutoの場合は入力の先頭から判定する
+ */
+func WithInputEncoding(encoding Encoding) Option {
+	return func(c *config) {
+		c.inputEncoding = encoding
+	}
+}
+
+/*
+* ConvertStreamの出力の文字コード
+* 指定しない場合はEncodingUtf8。EncodingAutoの場合は入力と同じ文字コードとする
+ */
+func WithOutputEncoding(encoding Encoding) Option {
+	return func(c *config) {
+		c.outputEncoding = encoding
+	}
+}
+
+func New(opts ...Option) (*Filter, error) {
+	c := config{
+		logger:         zap.NewNop(),
+		persona:        filters.DefaultPersona,
+		warningHandler: func(error) {},
+		inputEncoding:  EncodingUtf8,
+		outputEncoding: EncodingUtf8,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	f := &Filter{
+		warningHandler: c.warningHandler,
+		inputEncoding:  c.inputEncoding,
+		outputEncoding: c.outputEncoding,
+	}
+	mecabWrapper := &zunda_mecab.MecabWrapper{
+		Logger:   c.logger,
+		Options:  c.tokenizerOptions,
+		PoolSize: c.poolSize,
+	}
+	var zundaDb filters.ZundaDbController = zunda_mecab.ConjugatorRepository{}
+	if c.databasePath != "" {
+		repository, err := zunda_mecab.NewZundaDbRepository(c.databasePath, c.logger)
+		if err != nil {
+			return nil, err
+		}
+		f.repository = repository
+		zundaDb = repository
+		mecabWrapper.WordListLoader = repository
+	}
+	f.filter = &filters.ZundaFilter{
+		ZundaDb:       zundaDb,
+		MecabWrapper:  mecabWrapper,
+		Logger:        c.logger,
+		ErrorPolicy:   c.errorPolicy,
+		Filters:       c.filters,
+		DisabledRules: c.disabledRules,
+		Persona:       c.persona,
+		Stats:         c.stats,
+	}
+	// フィルタ名、規則名の誤りは変換時ではなく作成時に返す
+	if err := f.filter.Validate(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+/*
+* 変換
+* キャンセル、タイムアウト時は変換済みの文と未変換の残りを連結したテキストとPartialErrorを返す
+ */
+func (f *Filter) Convert(ctx context.Context, text string) (string, error) {
+	result, err := f.ConvertWithEdits(ctx, text)
+	return result.Text, err
+}
+
+/*
+* 置換範囲付きの変換
+* 変換結果には変換前後のテキストでの範囲(バイト位置、文字位置)と、置換したフィルタ、規則を含む
+* エラー、中断時の扱いはConvertと同じ
+ */
+func (f *Filter) ConvertWithEdits(ctx context.Context, text string) (ConvertResult, error) {
+	result, warnings, err := f.filter.ConvertEditsWithWarnings(ctx, text)
+	for _, warning := range warnings {
+		f.warningHandler(warning)
+	}
+	return result, err
+}
+
+/*
+* rから1文ずつ読み込んで変換し、wへ書き込む
+* 入力全体を読み込まないため、大きなファイルやパイプの入力に使う
+* キャンセル、タイムアウト時は残りを変換せずに書き込み、PartialErrorを返す
+* 入力、出力の文字コードで変換できないバイト、文字はバイト位置を含むエラー(ErrUnconvertible)とする
+ */
+func (f *Filter) ConvertStream(ctx context.Context, r io.Reader, w io.Writer) error {
+	reader, inputEncoding, err := charset.NewReader(r, f.inputEncoding)
+	if err != nil {
+		return err
+	}
+	outputEncoding := f.outputEncoding
+	if outputEncoding == EncodingAuto {
+		outputEncoding = inputEncoding
+	}
+	writer, err := charset.NewWriter(w, outputEncoding)
+	if err != nil {
+		return err
+	}
+	err = f.filter.ConvertStream(ctx, reader, writer, f.warningHandler)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+/*
+* rから1文ずつ読み込んで変換し、文ごとの変換結果(変換前後の文と規則ごとの置換範囲)をonResultへ渡す
+* 変換しなかった文は変換前と同じ変換結果とする
+ */
+func (f *Filter) ConvertEach(ctx context.Context, r io.Reader, onResult func(ConvertResult) error) error {
+	reader, _, err := charset.NewReader(r, f.inputEncoding)
+	if err != nil {
+		return err
+	}
+	return f.filter.ConvertEach(ctx, reader, onResult, f.warningHandler)
+}
+
+/*
+* 複数テキストの変換
+* エラー時は変換できたテキストまでを変換し、残りは元のテキストのまま返す
+ */
+func (f *Filter) ConvertBatch(ctx context.Context, texts []string) ([]string, error) {
+	convertedTexts := make([]string, len(texts))
+	copy(convertedTexts, texts)
+	for i, text := range texts {
+		convertedText, err := f.Convert(ctx, text)
+		if err != nil {
+			if convertedText != "" {
+				convertedTexts[i] = convertedText
+			}
+			return convertedTexts, err
+		}
+		convertedTexts[i] = convertedText
+	}
+	return convertedTexts, nil
+}
+
+func (f *Filter) Close() error {
+	f.filter.MecabWrapper.Close()
+	if f.repository == nil {
+		return nil
+	}
+	return f.repository.Close()
+}
@@ -0,0 +1,114 @@
+package zundafilter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"zundafilter/charset"
+)
+
+/*
+* 実行ファイルのdataディレクトリ、XDGのディレクトリが無くても作成できる
+ */
+func TestNew(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("ZUNDAFILTER_CONFIG", "")
+	t.Setenv("ZUNDAFILTER_DB", "")
+
+	tests := []struct {
+		name        string
+		options     []Option
+		expectError bool
+	}{
+		{
+			name: "指定なし",
+		},
+		{
+			name:    "フィルタ指定",
+			options: []Option{WithFilters(FilterHonorific, FilterPronoun), WithPersona(Persona{FirstPerson: "おいら"})},
+		},
+		{
+			name:        "存在しないフィルタ",
+			options:     []Option{WithFilters("unknown")},
+			expectError: true,
+		},
+		{
+			name:    "フィルタの順序変更と規則の無効化",
+			options: []Option{WithFilters(FilterPronoun, FilterMood), WithDisabledRules("mood.past", "honorific.specials")},
+		},
+		{
+			name:        "存在しない規則",
+			options:     []Option{WithDisabledRules("mood.unknown")},
+			expectError: true,
+		},
+		{
+			name:        "存在しないzunda.db",
+			options:     []Option{WithDatabasePath(filepath.Join(t.TempDir(), "zunda.db"))},
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			filter, err := New(testCase.options...)
+			if (err != nil) != testCase.expectError {
+				t.Fatalf("New() error = %v, expect error %v", err, testCase.expectError)
+			}
+			if filter != nil {
+				if err := filter.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertBatchCanceled(t *testing.T) {
+	filter, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer filter.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	texts := []string{"ここからです。", "体調不良でした。"}
+	actual, err := filter.ConvertBatch(ctx, texts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ConvertBatch() error = %v, expect %v", err, context.Canceled)
+	}
+	var partialError *PartialError
+	if !errors.As(err, &partialError) {
+		t.Fatalf("ConvertBatch() error = %v, expect PartialError", err)
+	}
+	// 未変換のテキストはそのまま返す
+	for i := range texts {
+		if actual[i] != texts[i] {
+			t.Fatalf("ConvertBatch()[%d] = %v, expect %v", i, actual[i], texts[i])
+		}
+	}
+}
+
+/*
+* 入力の文字コードで読み込めないバイトはバイト位置を含むエラーとする
+ */
+func TestConvertStreamInvalidEncoding(t *testing.T) {
+	filter, err := New(WithErrorPolicy(ErrorPolicyLenient), WithInputEncoding(EncodingShiftJis), WithOutputEncoding(EncodingAuto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer filter.Close()
+
+	// "はい\n"(Shift_JIS)の後に読み込めないバイト
+	input := []byte{0x82, 0xcd, 0x82, 0xa2, '\n', 0x81, 0x20}
+	err = filter.ConvertStream(context.Background(), bytes.NewReader(input), io.Discard)
+	var decodeError *charset.DecodeError
+	if !errors.Is(err, ErrUnconvertible) || !errors.As(err, &decodeError) || decodeError.Offset != 5 {
+		t.Fatalf("ConvertStream() error = %v, expect DecodeError at byte offset 5", err)
+	}
+}
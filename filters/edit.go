@@ -0,0 +1,407 @@
+package filters
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+	"zundafilter/zunda_mecab"
+)
+
+/*
+* テキスト中の範囲(Endは含まない)
+ */
+type Span struct {
+	Start     int // バイト位置
+	End       int
+	RuneStart int // 文字(rune)位置
+	RuneEnd   int
+}
+
+/*
+* 変換規則による置換
+* 同じ範囲に複数の規則を適用した場合は1つにまとめ、最後に適用した規則とする
+ */
+type Edit struct {
+	Filter      string // フィルタ名(FilterHonorific等)
+	Rule        string // 規則名
+	Original    Span   // 変換前のテキストでの範囲
+	Replacement Span   // 変換後のテキストでの範囲
+}
+
+/*
+* 変換結果
+ */
+type ConvertResult struct {
+	Original string // 変換前のテキスト
+	Text     string // 変換後のテキスト
+	Edits    []Edit // Original、Text上の範囲。位置順で重ならない
+}
+
+/*
+* 一部の置換のみを適用したテキスト
+* acceptがfalseを返した置換は変換前のまま残す
+ */
+func (r ConvertResult) Apply(accept func(Edit) bool) string {
+	var text strings.Builder
+	position := 0
+	for _, edit := range r.Edits {
+		text.WriteString(r.Original[position:edit.Original.Start])
+		if accept(edit) {
+			text.WriteString(r.Text[edit.Replacement.Start:edit.Replacement.End])
+		} else {
+			text.WriteString(r.Original[edit.Original.Start:edit.Original.End])
+		}
+		position = edit.Original.End
+	}
+	text.WriteString(r.Original[position:])
+	return text.String()
+}
+
+/*
+* 変換前のテキストのバイト位置を変換後のテキストの位置へ対応付ける
+* 置換範囲の内側、位置への挿入は、afterがtrueの場合は置換後の範囲の後ろ、falseの場合は前とする
+ */
+func (r ConvertResult) MapOffset(offset int, after bool) int {
+	shift := 0
+	for _, edit := range r.Edits {
+		original := edit.Original
+		if original.End < offset || (original.End == offset && (original.Start < offset || after)) {
+			shift = edit.Replacement.End - original.End
+			continue
+		}
+		if original.Start < offset {
+			if after {
+				return edit.Replacement.End
+			}
+			return edit.Replacement.Start
+		}
+		break
+	}
+	return offset + shift
+}
+
+/*
+* テキストの変換(zundafilter.Filter#ConvertWithEdits)
+* 入力の形式(Markdown、HTML、字幕等)ごとの変換から呼び出す
+ */
+type ConvertFunc func(ctx context.Context, text string) (ConvertResult, error)
+
+/*
+* 変換するテキストから取り除いた部分(タグ、記号、ルビ等)
+ */
+type Gap struct {
+	Offset int    // 取り除いた後のテキストでの位置
+	Text   string // 取り除いた文字列
+	After  bool   // 置換範囲の内側、位置への挿入の後ろへ戻す(閉じタグ等)
+}
+
+/*
+* 変換後のテキストの対応する位置へgapsを戻す
+* gapsはOffset順とし、同じ位置のgapは順序を保つ
+ */
+func (r ConvertResult) InsertGaps(gaps []Gap) string {
+	var text strings.Builder
+	position := 0
+	for _, gap := range gaps {
+		// 前のgapより前へは戻さない
+		offset := r.MapOffset(gap.Offset, gap.After)
+		if offset < position {
+			offset = position
+		}
+		text.WriteString(r.Text[position:offset])
+		text.WriteString(gap.Text)
+		position = offset
+	}
+	text.WriteString(r.Text[position:])
+	return text.String()
+}
+
+/*
+* 文ごとの変換結果の連結
+* 置換範囲を連結後のテキストの位置へずらす
+ */
+type convertResultBuilder struct {
+	original      strings.Builder
+	text          strings.Builder
+	originalRunes int
+	textRunes     int
+	edits         []Edit
+}
+
+func (b *convertResultBuilder) add(result ConvertResult) {
+	for _, edit := range result.Edits {
+		edit.Original = edit.Original.shift(b.original.Len(), b.originalRunes)
+		edit.Replacement = edit.Replacement.shift(b.text.Len(), b.textRunes)
+		b.edits = append(b.edits, edit)
+	}
+	b.original.WriteString(result.Original)
+	b.text.WriteString(result.Text)
+	b.originalRunes += utf8.RuneCountInString(result.Original)
+	b.textRunes += utf8.RuneCountInString(result.Text)
+}
+
+func (b *convertResultBuilder) result() ConvertResult {
+	edits := b.edits
+	if edits == nil {
+		edits = []Edit{}
+	}
+	return ConvertResult{Original: b.original.String(), Text: b.text.String(), Edits: edits}
+}
+
+func (s Span) shift(bytes int, runes int) Span {
+	return Span{Start: s.Start + bytes, End: s.End + bytes, RuneStart: s.RuneStart + runes, RuneEnd: s.RuneEnd + runes}
+}
+
+/*
+* 置換を重ねたテキスト
+* 変換前のテキストと変換後のテキストを区間の列で対応付ける
+ */
+type editedText struct {
+	segments []textSegment
+}
+
+type textSegment struct {
+	original string
+	text     string
+	changed  bool
+	filter   string
+	rule     string
+}
+
+func newEditedText(text string) *editedText {
+	e := &editedText{}
+	if text != "" {
+		e.segments = []textSegment{{original: text, text: text}}
+	}
+	return e
+}
+
+func (e *editedText) String() string {
+	var text strings.Builder
+	for _, segment := range e.segments {
+		text.WriteString(segment.text)
+	}
+	return text.String()
+}
+
+/*
+* 現在のテキストの[start, end)をreplacementへ置換する
+* 置換済みの区間にかかる場合は、その区間と合わせて1つの置換とする
+ */
+func (e *editedText) replace(start int, end int, replacement string, filter string, rule string) {
+	// 重なる区間[first, last)
+	first, last := len(e.segments), len(e.segments)
+	starts := make([]int, len(e.segments)+1)
+	for i, segment := range e.segments {
+		starts[i+1] = starts[i] + len(segment.text)
+		segmentStart, segmentEnd := starts[i], starts[i+1]
+		overlapped := segmentStart < end && segmentEnd > start
+		if start == end {
+			// 挿入は区間の内側の場合のみ重なるとする(区間の境界への挿入は別の置換とする)
+			overlapped = segmentStart < start && start < segmentEnd
+		}
+		if overlapped && first == len(e.segments) {
+			first = i
+		}
+		if overlapped {
+			last = i + 1
+		}
+	}
+	if first == len(e.segments) {
+		// どの区間にも重ならない挿入
+		first = 0
+		for first < len(e.segments) && starts[first] < start {
+			first++
+		}
+		last = first
+	}
+
+	merged := textSegment{
+		changed: true,
+		filter:  filter,
+		rule:    rule,
+	}
+	segments := append([]textSegment{}, e.segments[:first]...)
+	var prefix, suffix string
+	var rest []textSegment
+	for i := first; i < last; i++ {
+		segment := e.segments[i]
+		segmentStart, segmentEnd := starts[i], starts[i+1]
+		if segment.changed {
+			// 置換済みの区間は分割できないため全体を含める
+			merged.original += segment.original
+			if segmentStart < start {
+				prefix = segment.text[:start-segmentStart]
+			}
+			if segmentEnd > end {
+				suffix = segment.text[end-segmentStart:]
+			}
+			continue
+		}
+		// 変更されていない区間は置換の範囲外を残す
+		from, to := 0, len(segment.text)
+		if start > segmentStart {
+			from = start - segmentStart
+		}
+		if end < segmentEnd {
+			to = end - segmentStart
+		}
+		if from > 0 {
+			segments = append(segments, textSegment{original: segment.text[:from], text: segment.text[:from]})
+		}
+		merged.original += segment.text[from:to]
+		if to < len(segment.text) {
+			rest = append(rest, textSegment{original: segment.text[to:], text: segment.text[to:]})
+		}
+	}
+	merged.text = prefix + replacement + suffix
+	segments = append(segments, merged)
+	segments = append(segments, rest...)
+	e.segments = append(segments, e.segments[last:]...)
+}
+
+func (e *editedText) length() int {
+	length := 0
+	for _, segment := range e.segments {
+		length += len(segment.text)
+	}
+	return length
+}
+
+func (e *editedText) edits() []Edit {
+	edits := []Edit{}
+	original, text := Span{}, Span{}
+	for _, segment := range e.segments {
+		original = nextSpan(original, segment.original)
+		text = nextSpan(text, segment.text)
+		if segment.changed {
+			edits = append(edits, Edit{
+				Filter:      segment.filter,
+				Rule:        segment.rule,
+				Original:    original,
+				Replacement: text,
+			})
+		}
+	}
+	return edits
+}
+
+/*
+* spanの直後に続くtextの範囲
+ */
+func nextSpan(span Span, text string) Span {
+	return Span{
+		Start:     span.End,
+		End:       span.End + len(text),
+		RuneStart: span.RuneEnd,
+		RuneEnd:   span.RuneEnd + utf8.RuneCountInString(text),
+	}
+}
+
+/*
+* 形態素単位の変換の記録
+* 規則の適用前後の形態素列を比較し、変わった範囲を置換として記録する
+ */
+type featureEditor struct {
+	filter   string
+	features []zunda_mecab.MecabFeature
+	spans    []Span // featuresの現在のテキスト上の範囲
+	text     *editedText
+}
+
+/*
+* MeCabは空白を形態素に含めないため、形態素の位置はテキストから探す
+* 見つからない場合はテキスト全体を形態素を連結したテキストへ置換したものとする
+ */
+func newFeatureEditor(filter string, text string, features []zunda_mecab.MecabFeature) *featureEditor {
+	e := &featureEditor{
+		filter:   filter,
+		features: features,
+		spans:    make([]Span, len(features)),
+	}
+	position := 0
+	for i, feature := range features {
+		index := strings.Index(text[position:], feature.Word)
+		if index < 0 || strings.TrimSpace(text[position:position+index]) != "" {
+			return newConstructedFeatureEditor(filter, text, features)
+		}
+		e.spans[i] = Span{Start: position + index, End: position + index + len(feature.Word)}
+		position = e.spans[i].End
+	}
+	e.text = newEditedText(text)
+	return e
+}
+
+func newConstructedFeatureEditor(filter string, text string, features []zunda_mecab.MecabFeature) *featureEditor {
+	e := &featureEditor{
+		filter:   filter,
+		features: features,
+		spans:    make([]Span, len(features)),
+	}
+	position := 0
+	var constructed strings.Builder
+	for i, feature := range features {
+		e.spans[i] = Span{Start: position, End: position + len(feature.Word)}
+		position = e.spans[i].End
+		constructed.WriteString(feature.Word)
+	}
+	e.text = newEditedText(text)
+	if constructed.String() != text {
+		e.text.replace(0, len(text), constructed.String(), filter, "tokenize")
+	}
+	return e
+}
+
+/*
+* 規則の適用結果を記録する
+* return: 単語が変わったか
+ */
+func (e *featureEditor) apply(rule string, features []zunda_mecab.MecabFeature) bool {
+	// 前後の一致する形態素を除いた範囲が置換された
+	prefix := 0
+	for prefix < len(e.features) && prefix < len(features) && e.features[prefix].Word == features[prefix].Word {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(e.features)-prefix && suffix < len(features)-prefix && e.features[len(e.features)-1-suffix].Word == features[len(features)-1-suffix].Word {
+		suffix++
+	}
+	replaced := e.features[prefix : len(e.features)-suffix]
+	replacing := features[prefix : len(features)-suffix]
+
+	var replacement strings.Builder
+	for _, feature := range replacing {
+		replacement.WriteString(feature.Word)
+	}
+	start := e.text.length()
+	if prefix < len(e.spans) {
+		start = e.spans[prefix].Start
+	}
+	end := start
+	if len(replaced) > 0 {
+		end = e.spans[prefix+len(replaced)-1].End
+	}
+	changed := e.text.String()[start:end] != replacement.String()
+	if changed {
+		e.text.replace(start, end, replacement.String(), e.filter, rule)
+	}
+
+	// 置換した形態素以降の位置を更新する
+	spans := append([]Span{}, e.spans[:prefix]...)
+	position := start
+	for _, feature := range replacing {
+		spans = append(spans, Span{Start: position, End: position + len(feature.Word)})
+		position += len(feature.Word)
+	}
+	shift := position - end
+	for _, span := range e.spans[len(e.spans)-suffix:] {
+		spans = append(spans, Span{Start: span.Start + shift, End: span.End + shift})
+	}
+	e.features = features
+	e.spans = spans
+	return changed
+}
+
+func (e *featureEditor) result() (string, []Edit) {
+	return e.text.String(), e.text.edits()
+}
@@ -0,0 +1,273 @@
+package filters
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"zundafilter/zunda_mecab"
+)
+
+func testFeatures(words ...string) []zunda_mecab.MecabFeature {
+	features := []zunda_mecab.MecabFeature{}
+	for _, word := range words {
+		features = append(features, zunda_mecab.MecabFeature{Word: word})
+	}
+	return features
+}
+
+func TestFeatureEditor(t *testing.T) {
+	type application struct {
+		rule  string
+		words []string
+	}
+	tests := []struct {
+		name         string
+		text         string
+		words        []string
+		applications []application
+		expect       string
+		expectEdits  []Edit
+	}{
+		{
+			name:         "置換",
+			text:         "私は行きます",
+			words:        []string{"私", "は", "行き", "ます"},
+			applications: []application{{rule: "pronoun", words: []string{"ぼく", "は", "行き", "ます"}}},
+			expect:       "ぼくは行きます",
+			expectEdits:  []Edit{{Filter: FilterPronoun, Rule: "pronoun", Original: Span{0, 3, 0, 1}, Replacement: Span{0, 6, 0, 2}}},
+		},
+		{
+			name:         "空白を残す",
+			text:         "私 は 行きます",
+			words:        []string{"私", "は", "行き", "ます"},
+			applications: []application{{rule: "pronoun", words: []string{"ぼく", "は", "行き", "ます"}}},
+			expect:       "ぼく は 行きます",
+			expectEdits:  []Edit{{Filter: FilterPronoun, Rule: "pronoun", Original: Span{0, 3, 0, 1}, Replacement: Span{0, 6, 0, 2}}},
+		},
+		{
+			name:  "削除と挿入",
+			text:  "行きます",
+			words: []string{"行き", "ます"},
+			applications: []application{
+				{rule: "verb", words: []string{"行く"}},
+				{rule: "affirmative", words: []string{"行く", "のだ"}},
+			},
+			expect: "行くのだ",
+			expectEdits: []Edit{
+				{Filter: FilterPronoun, Rule: "verb", Original: Span{0, 12, 0, 4}, Replacement: Span{0, 6, 0, 2}},
+				{Filter: FilterPronoun, Rule: "affirmative", Original: Span{12, 12, 4, 4}, Replacement: Span{6, 12, 2, 4}},
+			},
+		},
+		{
+			name:  "重なる置換はまとめる",
+			text:  "私は行きます",
+			words: []string{"私", "は", "行き", "ます"},
+			applications: []application{
+				{rule: "verb", words: []string{"私", "は", "行く"}},
+				{rule: "past", words: []string{"私", "は", "行った"}},
+			},
+			expect:      "私は行った",
+			expectEdits: []Edit{{Filter: FilterPronoun, Rule: "past", Original: Span{6, 18, 2, 6}, Replacement: Span{6, 15, 2, 5}}},
+		},
+		{
+			name:         "単語の変わらない規則",
+			text:         "行きます",
+			words:        []string{"行き", "ます"},
+			applications: []application{{rule: "remove", words: []string{"行き", "ます"}}},
+			expect:       "行きます",
+			expectEdits:  []Edit{},
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			editor := newFeatureEditor(FilterPronoun, testCase.text, testFeatures(testCase.words...))
+			for _, application := range testCase.applications {
+				editor.apply(application.rule, testFeatures(application.words...))
+			}
+			actual, edits := editor.result()
+			if actual != testCase.expect {
+				t.Fatalf("featureEditor.result() = %v, expect %v", actual, testCase.expect)
+			}
+			if !reflect.DeepEqual(edits, testCase.expectEdits) {
+				t.Fatalf("featureEditor.result() edits = %+v, expect %+v", edits, testCase.expectEdits)
+			}
+		})
+	}
+}
+
+/*
+* フィルタごとの置換を変換前の位置へ合成する
+ */
+func TestZundaFilterConvertSentenceEdits(t *testing.T) {
+	filter := ZundaFilter{Logger: getTestLogger()}
+	converters := []Converter{
+		&stubConverter{from: "私", to: "ぼく", filter: FilterPronoun, rule: "pronoun"},
+		&stubConverter{from: "ます", to: "のだ", filter: FilterMood, rule: "affirmative"},
+		&stubConverter{from: "ぼくは", to: "ぼくが", filter: FilterHonorific, rule: "specials"},
+	}
+	actual, err := filter.convertSentence(context.Background(), converters, "私は行きます。")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := ConvertResult{
+		Original: "私は行きます。",
+		Text:     "ぼくが行きのだ。",
+		Edits: []Edit{
+			{Filter: FilterHonorific, Rule: "specials", Original: Span{0, 6, 0, 2}, Replacement: Span{0, 9, 0, 3}},
+			{Filter: FilterMood, Rule: "affirmative", Original: Span{12, 18, 4, 6}, Replacement: Span{15, 21, 5, 7}},
+		},
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Fatalf("ZundaFilter.convertSentence() = %+v, expect %+v", actual, expect)
+	}
+}
+
+/*
+* 文ごとの変換結果を連結しても置換範囲が連結後のテキストを指す
+ */
+func TestConvertResultBuilder(t *testing.T) {
+	filter := ZundaFilter{Logger: getTestLogger()}
+	converters := []Converter{
+		&stubConverter{from: "私", to: "ぼく", filter: FilterPronoun, rule: "pronoun"},
+	}
+	builder := convertResultBuilder{}
+	for _, sentence := range SplitSentences("はい。私です。\n私は私。") {
+		result, err := filter.convertSentence(context.Background(), converters, sentence)
+		if err != nil {
+			t.Fatal(err)
+		}
+		builder.add(result)
+	}
+	actual := builder.result()
+	if actual.Text != "はい。ぼくです。\nぼくは私。" {
+		t.Fatalf("convertResultBuilder.result() = %v", actual.Text)
+	}
+	for _, edit := range actual.Edits {
+		if actual.Original[edit.Original.Start:edit.Original.End] != "私" || actual.Text[edit.Replacement.Start:edit.Replacement.End] != "ぼく" {
+			t.Fatalf("convertResultBuilder.result() edit = %+v, expect 私 -> ぼく", edit)
+		}
+		if string([]rune(actual.Original)[edit.Original.RuneStart:edit.Original.RuneEnd]) != "私" || string([]rune(actual.Text)[edit.Replacement.RuneStart:edit.Replacement.RuneEnd]) != "ぼく" {
+			t.Fatalf("convertResultBuilder.result() edit = %+v, expect rune range of 私 -> ぼく", edit)
+		}
+	}
+	if len(actual.Edits) != 2 {
+		t.Fatalf("convertResultBuilder.result() edits = %+v, expect 2", actual.Edits)
+	}
+
+	// 置換の一部だけを適用する
+	rejectFirst := func(edit Edit) bool {
+		return edit.Original.Start != actual.Edits[0].Original.Start
+	}
+	if applied := actual.Apply(rejectFirst); applied != "はい。私です。\nぼくは私。" {
+		t.Fatalf("ConvertResult.Apply() = %v, expect はい。私です。\nぼくは私。", applied)
+	}
+}
+
+func TestConvertResultMapOffset(t *testing.T) {
+	// "ab です cd" -> "ab なのだ cd!"(「です」の置換と末尾への挿入)
+	result := ConvertResult{
+		Original: "ab です cd",
+		Text:     "ab なのだ cd!",
+		Edits: []Edit{
+			{Original: Span{Start: 3, End: 9}, Replacement: Span{Start: 3, End: 12}},
+			{Original: Span{Start: 12, End: 12}, Replacement: Span{Start: 15, End: 16}},
+		},
+	}
+	tests := []struct {
+		name   string
+		offset int
+		after  bool
+		expect int
+	}{
+		{name: "置換の前", offset: 1, expect: 1},
+		{name: "置換の開始位置", offset: 3, after: true, expect: 3},
+		{name: "置換の内側(前)", offset: 6, expect: 3},
+		{name: "置換の内側(後ろ)", offset: 6, after: true, expect: 12},
+		{name: "置換の終了位置", offset: 9, expect: 12},
+		{name: "置換の後ろ", offset: 10, expect: 13},
+		{name: "挿入の前", offset: 12, expect: 15},
+		{name: "挿入の後ろ", offset: 12, after: true, expect: 16},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := result.MapOffset(testCase.offset, testCase.after); actual != testCase.expect {
+				t.Fatalf("ConvertResult.MapOffset(%d, %v) = %d, expect %d", testCase.offset, testCase.after, actual, testCase.expect)
+			}
+		})
+	}
+}
+
+func TestConvertResultInsertGaps(t *testing.T) {
+	// "ab です cd" -> "ab なのだ cd!"(「です」の置換と末尾への挿入)
+	result := ConvertResult{
+		Original: "ab です cd",
+		Text:     "ab なのだ cd!",
+		Edits: []Edit{
+			{Original: Span{Start: 3, End: 9}, Replacement: Span{Start: 3, End: 12}},
+			{Original: Span{Start: 12, End: 12}, Replacement: Span{Start: 15, End: 16}},
+		},
+	}
+	tests := []struct {
+		name   string
+		gaps   []Gap
+		expect string
+	}{
+		{
+			name:   "置換の前後",
+			gaps:   []Gap{{Offset: 3, Text: "<b>"}, {Offset: 9, Text: "</b>", After: true}},
+			expect: "ab <b>なのだ</b> cd!",
+		},
+		{
+			name:   "置換の内側",
+			gaps:   []Gap{{Offset: 3, Text: "<b>"}, {Offset: 6, Text: "<i>"}, {Offset: 6, Text: "</i>", After: true}, {Offset: 9, Text: "</b>", After: true}},
+			expect: "ab <b><i>なのだ</i></b> cd!",
+		},
+		{
+			name:   "挿入の前後",
+			gaps:   []Gap{{Offset: 12, Text: "<br>"}, {Offset: 12, Text: "</p>", After: true}},
+			expect: "ab なのだ cd<br>!</p>",
+		},
+		{
+			name:   "戻す位置が前のgapより前",
+			gaps:   []Gap{{Offset: 6, Text: "</i>", After: true}, {Offset: 6, Text: "<i>"}},
+			expect: "ab なのだ</i><i> cd!",
+		},
+	}
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := result.InsertGaps(testCase.gaps); actual != testCase.expect {
+				t.Fatalf("ConvertResult.InsertGaps() = %q, expect %q", actual, testCase.expect)
+			}
+		})
+	}
+}
+
+/*
+* 最初に見つかった文字列を置換する変換
+ */
+type stubConverter struct {
+	from   string
+	to     string
+	filter string
+	rule   string
+}
+
+func (c *stubConverter) Convert(text string) (string, error) {
+	return c.ConvertContext(context.Background(), text)
+}
+
+func (c *stubConverter) ConvertContext(ctx context.Context, text string) (string, error) {
+	result, err := c.ConvertEdits(ctx, text)
+	return result.Text, err
+}
+
+func (c *stubConverter) ConvertEdits(ctx context.Context, text string) (ConvertResult, error) {
+	edited := newEditedText(text)
+	if index := strings.Index(text, c.from); index >= 0 {
+		edited.replace(index, index+len(c.from), c.to, c.filter, c.rule)
+	}
+	return ConvertResult{Original: text, Text: edited.String(), Edits: edited.edits()}, nil
+}
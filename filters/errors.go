@@ -0,0 +1,101 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"zundafilter/zunda_mecab"
+)
+
+/*
+* 変換エラーの種類
+* errors.Is(err, ErrTokenizerUnavailable)等で判定する
+ */
+var (
+	ErrTokenizerUnavailable = zunda_mecab.ErrTokenizerUnavailable // MeCabを起動できない、解析できない
+	ErrDictionaryMissing    = errors.New("dictionary missing")    // zunda.dbを参照できない
+	ErrRuleFailed           = errors.New("rule failed")           // 変換規則を適用できない(活用形が見つからない等)。その規則を飛ばして変換を続ける
+)
+
+/*
+* 変換規則の実行時エラー
+* ex) HonorificFilter#convertVerbBeforeHonorificNegative: rule failed: ConjugationTable: 渡す(五段・サ行, 未然形) is not found
+ */
+type ConvertError struct {
+	Filter string
+	Rule   string
+	Kind   error // ErrTokenizerUnavailable, ErrDictionaryMissing, ErrRuleFailed
+	Err    error
+}
+
+func (e *ConvertError) Error() string {
+	return fmt.Sprintf("%s#%s: %v: %v", e.Filter, e.Rule, e.Kind, e.Err)
+}
+
+func (e *ConvertError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ConvertError) Unwrap() error {
+	return e.Err
+}
+
+/*
+* 原因からエラーの種類を判定してConvertErrorを作成する
+* 該当行が無い場合は変換規則の失敗、それ以外のzunda.dbの失敗は辞書の参照失敗とする
+* キャンセル、タイムアウトは変換エラーとしない
+ */
+func newConvertError(filter string, rule string, err error) error {
+	// キャンセル、タイムアウトはそのまま返す
+	if isContextError(err) {
+		return err
+	}
+	kind := ErrDictionaryMissing
+	switch {
+	case errors.Is(err, ErrTokenizerUnavailable):
+		kind = ErrTokenizerUnavailable
+	case errors.Is(err, zunda_mecab.ErrNotFound):
+		kind = ErrRuleFailed
+	}
+	return &ConvertError{
+		Filter: filter,
+		Rule:   rule,
+		Kind:   kind,
+		Err:    err,
+	}
+}
+
+/*
+* 変換規則の失敗(活用形が見つからない等)は警告を出力してその規則を飛ばす(nilを返す)
+* MeCab、zunda.dbの失敗、キャンセル、タイムアウトはそのまま返す
+ */
+func skipRuleFailure(logger *zap.Logger, err error) error {
+	if !errors.Is(err, ErrRuleFailed) {
+		return err
+	}
+	if ce := logger.Check(zap.WarnLevel, "skip rule"); ce != nil {
+		ce.Write(zap.Error(err))
+	}
+	return nil
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+/*
+* 入力の形式(Markdown、HTML、字幕等)ごとの変換で、変換できなかった行
+ */
+type LineError struct {
+	Line int // 変換できなかったテキストの開始行
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
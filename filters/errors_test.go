@@ -0,0 +1,90 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"zundafilter/zunda_mecab"
+)
+
+func TestNewConvertError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect error
+	}{
+		{
+			name:   "MeCabの失敗",
+			err:    &zunda_mecab.TokenizerError{Op: "new", Err: errors.New("no such file or directory")},
+			expect: ErrTokenizerUnavailable,
+		},
+		{
+			name:   "活用形が見つからない",
+			err:    &zunda_mecab.NotFoundError{Table: "ConjugationTable", Key: "渡す"},
+			expect: ErrRuleFailed,
+		},
+		{
+			name:   "zunda.dbの失敗",
+			err:    errors.New("no such table: ReplacementTable"),
+			expect: ErrDictionaryMissing,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			err := newConvertError("HonorificFilter", "convertSpecials", testCase.err)
+			if !errors.Is(err, testCase.expect) {
+				t.Fatalf("newConvertError() = %v, expect %v", err, testCase.expect)
+			}
+			if !errors.Is(err, testCase.err) {
+				t.Fatalf("newConvertError() = %v, expect to wrap %v", err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestSkipRuleFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect error
+	}{
+		{
+			name:   "活用形が見つからない",
+			err:    newConvertError("HonorificFilter", "convertVerbBeforeHonorificNegative", &zunda_mecab.NotFoundError{Table: "ConjugationTable", Key: "渡す"}),
+			expect: nil,
+		},
+		{
+			name:   "MeCabの失敗",
+			err:    newConvertError("HonorificFilter", "Convert", &zunda_mecab.TokenizerError{Op: "parse", Err: errors.New("failed")}),
+			expect: ErrTokenizerUnavailable,
+		},
+		{
+			name:   "zunda.dbの失敗",
+			err:    newConvertError("HonorificFilter", "convertSpecials", errors.New("database is locked")),
+			expect: ErrDictionaryMissing,
+		},
+		{
+			name:   "キャンセル",
+			err:    newConvertError("HonorificFilter", "convertSpecials", context.Canceled),
+			expect: context.Canceled,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			err := skipRuleFailure(getTestLogger(), testCase.err)
+			if testCase.expect == nil {
+				if err != nil {
+					t.Fatalf("skipRuleFailure() = %v, expect nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, testCase.expect) {
+				t.Fatalf("skipRuleFailure() = %v, expect %v", err, testCase.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package filterstest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"zundafilter/filters"
+)
+
+// ConvertTextが返すエラー
+var ErrConvert = errors.New("convert error")
+
+/*
+* 置換する語と変換規則
+ */
+var replacements = []struct {
+	from   string
+	to     string
+	filter string
+	rule   string
+}{
+	{from: "です", to: "なのだ", filter: filters.FilterMood, rule: "affirmative"},
+	{from: "私", to: "ぼく", filter: filters.FilterPronoun, rule: "pronoun"},
+}
+
+/*
+* テスト用の変換(filters.ConvertFunc)
+* 「です」を「なのだ」、「私」を「ぼく」に置換し、「食べる」の後に「のだ」を挿入する
+* 「error」を含むテキストはErrConvertとする
+ */
+func ConvertText(ctx context.Context, text string) (filters.ConvertResult, error) {
+	if strings.Contains(text, "error") {
+		return filters.ConvertResult{}, ErrConvert
+	}
+	result := filters.ConvertResult{Original: text, Edits: []filters.Edit{}}
+	var converted strings.Builder
+	for position := 0; position < len(text); {
+		replaced := false
+		for _, replacement := range replacements {
+			if strings.HasPrefix(text[position:], replacement.from) {
+				result.Edits = append(result.Edits, filters.Edit{
+					Filter:      replacement.filter,
+					Rule:        replacement.rule,
+					Original:    filters.Span{Start: position, End: position + len(replacement.from)},
+					Replacement: filters.Span{Start: converted.Len(), End: converted.Len() + len(replacement.to)},
+				})
+				converted.WriteString(replacement.to)
+				position += len(replacement.from)
+				replaced = true
+				break
+			}
+		}
+		if replaced {
+			continue
+		}
+		if strings.HasPrefix(text[position:], "食べる") {
+			converted.WriteString("食べる")
+			position += len("食べる")
+			result.Edits = append(result.Edits, filters.Edit{
+				Filter:      filters.FilterMood,
+				Rule:        "affirmative",
+				Original:    filters.Span{Start: position, End: position},
+				Replacement: filters.Span{Start: converted.Len(), End: converted.Len() + len("のだ")},
+			})
+			converted.WriteString("のだ")
+			continue
+		}
+		converted.WriteByte(text[position])
+		position++
+	}
+	result.Text = converted.String()
+	return result, nil
+}
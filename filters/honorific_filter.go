@@ -1,53 +1,141 @@
 package filters
 
 import (
+	"context"
 	"go.uber.org/zap"
 	"strings"
 	"zundafilter/zunda_mecab"
 )
 
+const honorificFilterName = "HonorificFilter"
+
 type HonorificFilter struct {
-	ZundaDb      ZundaDbController
-	MecabWrapper *zunda_mecab.MecabWrapper
-	Logger       *zap.Logger
+	ZundaDb       ZundaDbController
+	MecabWrapper  *zunda_mecab.MecabWrapper
+	Logger        *zap.Logger
+	DisabledRules []string // 適用しない規則名(honorificRulesのName)
+	Stats         *Stats
+}
+
+/*
+* 敬語の変換規則
+* 並び順が適用順(全ての規則を順に適用する)
+ */
+type honorificRule struct {
+	Name    string
+	Convert func(*HonorificFilter, context.Context, []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error)
+}
+
+var honorificRules = []honorificRule{
+	{Name: "verb_past_negative", Convert: (*HonorificFilter).convertVerbBeforePastHonorificNegative},
+	{Name: "sahen_verb_past", Convert: (*HonorificFilter).convertSahenVerbBeforePastHorific},
+	{Name: "verb_past_hatsu_onbin", Convert: (*HonorificFilter).convertVerbBeforePastHorificHatsuOnbin},
+	{Name: "verb_past_i_onbin", Convert: (*HonorificFilter).convertVerbBeforePastHorificIOnbin},
+	{Name: "verb_past_soku_onbin", Convert: (*HonorificFilter).convertVerbBeforePastHorificSokuOnbin},
+	{Name: "noun_past", Convert: (*HonorificFilter).convertNounBeforePastHorific},
+	{Name: "verb_negative", Convert: (*HonorificFilter).convertVerbBeforeHonorificNegative},
+	{Name: "remove_past", Convert: (*HonorificFilter).removePastHonorificWord},
+	{Name: "verb", Convert: (*HonorificFilter).convertVerbBeforeHonorific},
+	{Name: "specials", Convert: (*HonorificFilter).convertSpecials},
+	{Name: "remove", Convert: (*HonorificFilter).removeHonorificWord},
 }
 
+var honorificFilterDefinition = FilterDefinition{
+	Name:  FilterHonorific,
+	Rules: honorificRuleNames(),
+	New: func(z *ZundaFilter, disabledRules []string) Converter {
+		return &HonorificFilter{
+			ZundaDb:       z.ZundaDb,
+			MecabWrapper:  z.MecabWrapper,
+			Logger:        z.Logger,
+			DisabledRules: disabledRules,
+			Stats:         z.Stats,
+		}
+	},
+}
+
+func honorificRuleNames() []string {
+	names := []string{}
+	for _, rule := range honorificRules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
+
+// 敬語変換の条件(起動時に一度だけコンパイルする)
+var (
+	honorificWordsMatcher                     = zunda_mecab.CompileMecabMatcher(getHonorificWords())
+	pastHonorificWordsMatcher                 = zunda_mecab.CompileMecabMatcher(getPastHonorificWords())
+	verbBeforeHonorificNegativeConditions     = getVerbBeforeHonorificNegativeConditions()
+	verbBeforeHonorificNegativeMatcher        = zunda_mecab.CompileMecabMatcher(verbBeforeHonorificNegativeConditions)
+	verbBeforePastHonorificNegativeConditions = getVerbBeforePastHonorificNegativeConditions()
+	verbBeforePastHonorificNegativeMatcher    = zunda_mecab.CompileMecabMatcher(verbBeforePastHonorificNegativeConditions)
+	sahenVerbBeforePastHorificMatcher         = zunda_mecab.CompileMecabMatcher(getSahenVerbBeforePastHorificConditions())
+	nounBeforePastHorificMatcher              = zunda_mecab.CompileMecabMatcher(getNounBeforePastHorificConditions())
+	verbBeforePastHorificOnbinMatcher         = zunda_mecab.CompileMecabMatcher(getVerbBeforePastHorificOnbinConditions("た"))
+)
+
 func (h *HonorificFilter) Convert(text string) (string, error) {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("HonorificFilter#Convert()")
+	return h.ConvertContext(context.Background(), text)
+}
+
+func (h *HonorificFilter) ConvertContext(ctx context.Context, text string) (string, error) {
+	result, err := h.ConvertEdits(ctx, text)
+	return result.Text, err
+}
 
-	features, err := h.MecabWrapper.ParseToNode(text)
+func (h *HonorificFilter) ConvertEdits(ctx context.Context, text string) (ConvertResult, error) {
+	h.Logger.Debug("HonorificFilter#Convert()")
+
+	features, err := h.MecabWrapper.ParseToNodeContext(ctx, text)
 	if err != nil {
-		return "", nil
-	}
-
-	convertedFeatures := convert(
-		features,
-		[]func([]zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature{
-			h.convertVerbBeforePastHonorificNegative,
-			h.convertSahenVerbBeforePastHorific,
-			h.convertVerbBeforePastHorificHatsuOnbin,
-			h.convertVerbBeforePastHorificIOnbin,
-			h.convertVerbBeforePastHorificSokuOnbin,
-			h.convertNounBeforePastHorific,
-			h.convertVerbBeforeHonorificNegative,
-			h.removePastHonorificWord,
-			h.convertVerbBeforeHonorific,
-			h.convertSpecials,
-			h.removeHonorificWord,
-		})
+		return ConvertResult{}, newConvertError(honorificFilterName, "Convert", err)
+	}
 
-	return h.MecabWrapper.Construct(convertedFeatures), nil
+	editor := newFeatureEditor(FilterHonorific, text, features)
+	converters := []func(context.Context, []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error){}
+	for _, rule := range honorificRules {
+		if containsName(h.DisabledRules, rule.Name) {
+			continue
+		}
+		rule := rule
+		converters = append(converters, func(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+			convertedFeatures, err := rule.Convert(h, ctx, features)
+			if err != nil {
+				return features, skipRuleFailure(h.Logger, err)
+			}
+			if editor.apply(rule.Name, convertedFeatures) {
+				h.Stats.addRuleHit(FilterHonorific, rule.Name)
+			}
+			return convertedFeatures, nil
+		})
+	}
+	if _, err := convert(ctx, features, converters); err != nil {
+		return ConvertResult{}, err
+	}
 
+	convertedText, edits := editor.result()
+	return ConvertResult{Original: text, Text: convertedText, Edits: edits}, nil
 }
 
-func convert(features []zunda_mecab.MecabFeature, converters []func([]zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
+/*
+* 変換規則の順次適用
+* 規則の間でキャンセルを確認する
+ */
+func convert(ctx context.Context, features []zunda_mecab.MecabFeature, converters []func(context.Context, []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error)) ([]zunda_mecab.MecabFeature, error) {
 	if len(converters) == 0 {
-		return features
+		return features, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return features, err
+	}
+	convertedFeatures, err := converters[0](ctx, features)
+	if err != nil {
+		return features, err
 	}
 	return convert(
-		converters[0](features),
+		ctx,
+		convertedFeatures,
 		converters[1:],
 	)
 }
@@ -61,28 +149,16 @@ func getHonorificWords() []zunda_mecab.MecabCondition {
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
 				{
-					CheckWord:         true,
-					Word:              "です",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeAuxiliaryVerb,
-					CheckOriginalForm: false,
-					OriginalForm:      "",
-				},
-				{
-					CheckWord:         true,
-					Word:              "ます",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeAuxiliaryVerb,
-					CheckOriginalForm: false,
-					OriginalForm:      "",
+					CheckWordSet:  true,
+					WordSet:       []string{"です", "ます"},
+					CheckWordType: true,
+					WordType:      zunda_mecab.MecabWordTypeAuxiliaryVerb,
 				},
 				{
-					CheckWord:         true,
-					Word:              "ですが",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeConjunction,
-					CheckOriginalForm: false,
-					OriginalForm:      "",
+					CheckWord:     true,
+					Word:          "ですが",
+					CheckWordType: true,
+					WordType:      zunda_mecab.MecabWordTypeConjunction,
 				},
 			},
 		},
@@ -90,16 +166,10 @@ func getHonorificWords() []zunda_mecab.MecabCondition {
 }
 
 /*
-* 動詞 + 敬語(否定)の対応
-* ex) ここから動きません -> ここから動かない
+* 動詞 + 敬語(否定)の対応 条件
  */
-func (h *HonorificFilter) convertVerbBeforeHonorificNegative(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-
-	sugar.Debug("convertVerbBeforeHonorificNegative()")
-	conditions := []zunda_mecab.MecabCondition{
-
+func getVerbBeforeHonorificNegativeConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -140,9 +210,18 @@ func (h *HonorificFilter) convertVerbBeforeHonorificNegative(features []zunda_me
 			},
 		},
 	}
-	match, index := h.MecabWrapper.GetMatchIndex(features, conditions)
+}
+
+/*
+* 動詞 + 敬語(否定)の対応
+* ex) ここから動きません -> ここから動かない
+ */
+func (h *HonorificFilter) convertVerbBeforeHonorificNegative(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+
+	h.Logger.Debug("convertVerbBeforeHonorificNegative()")
+	match, index := h.MecabWrapper.GetCompiledMatchIndex(verbBeforeHonorificNegativeMatcher, features)
 	if !match {
-		return features
+		return features, nil
 	}
 
 	// 条件:
@@ -150,40 +229,37 @@ func (h *HonorificFilter) convertVerbBeforeHonorificNegative(features []zunda_me
 	//  + 敬語の一つ後が「ん」
 	texts := []string{}
 	texts = append(texts, h.MecabWrapper.Construct(features[:index]))
-	conjugationForm, err := h.ZundaDb.SelectConvertVerbConjugationTable(features[index].OriginalForm)
+	mizen, err := h.ZundaDb.SelectConjugation(features[index].OriginalForm, features[index].ConjugationType, zunda_mecab.MecabConjugationFormMizen)
 	if err != nil {
-		sugar.Errorf("convertVerbBeforeHonorificNegative() - error: %v", err)
-		return features
+		return features, newConvertError(honorificFilterName, "convertVerbBeforeHonorificNegative", err)
 	}
-	texts = append(texts, conjugationForm.Mizen)
+	texts = append(texts, mizen.Word)
 	texts = append(texts, "ない")
-	if (index + len(conditions)) <= len(features) {
-		texts = append(texts, h.MecabWrapper.Construct(features[index+len(conditions):]))
+	if (index + len(verbBeforeHonorificNegativeConditions)) <= len(features) {
+		texts = append(texts, h.MecabWrapper.Construct(features[index+len(verbBeforeHonorificNegativeConditions):]))
 	}
 
-	exchangedFeatures, err := h.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangedFeatures, err := h.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertVerbBeforeHonorificNegative() - error: %v", err)
-		return features
+		return features, newConvertError(honorificFilterName, "convertVerbBeforeHonorificNegative", err)
+	}
+	if ce := h.Logger.Check(zap.InfoLevel, "convertVerbBeforeHonorificNegative()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
 	}
-	sugar.Infof("convertVerbBeforeHonorificNegative() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+	return exchangedFeatures, nil
 
 }
 
 /*
 * 動詞変換を含む敬語解除(現在)
  */
-func (h *HonorificFilter) convertVerbBeforeHonorific(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
+func (h *HonorificFilter) convertVerbBeforeHonorific(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
 
-	sugar.Debug("convertVerbBeforeHonorific()")
+	h.Logger.Debug("convertVerbBeforeHonorific()")
 
-	conditions := getHonorificWords()
-	match, index := h.MecabWrapper.GetMatchIndex(features, conditions)
+	match, index := h.MecabWrapper.GetCompiledMatchIndex(honorificWordsMatcher, features)
 	if !match {
-		return features
+		return features, nil
 	}
 
 	// 条件:
@@ -191,13 +267,13 @@ func (h *HonorificFilter) convertVerbBeforeHonorific(features []zunda_mecab.Meca
 	// + 動詞が原形ではない
 	// + 敬語が現在形
 	if index < 1 {
-		return features
+		return features, nil
 	}
 	if features[index-1].WordType != zunda_mecab.MecabWordTypeVerb {
-		return features
+		return features, nil
 	}
 	if features[index-1].Word == features[index-1].OriginalForm {
-		return features
+		return features, nil
 	}
 	exchangedFeatures := []zunda_mecab.MecabFeature{}
 	exchangedFeatures = append(exchangedFeatures, features[:index-1]...)
@@ -213,82 +289,88 @@ func (h *HonorificFilter) convertVerbBeforeHonorific(features []zunda_mecab.Meca
 		OriginalForm:    features[index-1].OriginalForm,
 	})
 	exchangedFeatures = append(exchangedFeatures, features[index:]...)
-	sugar.Infof("convertVerbBeforeHonorific() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+	if ce := h.Logger.Check(zap.InfoLevel, "convertVerbBeforeHonorific()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
 }
 
 /*
-* 特定敬語(現在)の変換(「ですが」など)
+* 組み込みの置換(置換辞書より後に照合する)
  */
-func (h *HonorificFilter) convertSpecials(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("convertSpecials()")
-
-	conditions := []struct {
-		Condition []zunda_mecab.MecabCondition
-		DistWord  string
-	}{
-		{
-			Condition: []zunda_mecab.MecabCondition{
-				{
-					ConditionType: zunda_mecab.MecabConditionTypeOne,
-					Features: []zunda_mecab.MecabConditionFeature{
-						{
-							CheckWord:         true,
-							Word:              "ですが",
-							CheckWordType:     true,
-							WordType:          zunda_mecab.MecabWordTypeConjunction,
-							CheckOriginalForm: false,
-							OriginalForm:      "",
-						},
-					},
-				},
-			},
-			DistWord: "だけど",
-		},
-	}
+var specialReplacements = []zunda_mecab.ReplacementRow{
+	{
+		Source:   "ですが",
+		Key:      zunda_mecab.ReplacementKeyWord,
+		WordType: zunda_mecab.MecabWordTypeConjunction,
+		Target:   "だけど",
+	},
+}
 
-	var exchangedFeatures = features
-	for _, condition := range conditions {
-		sugar.Debugf("convertSpecials() special: %s", condition.Condition[0].String())
-		match, index := h.MecabWrapper.GetMatchIndex(features, condition.Condition)
-		if !match {
-			continue
-		}
-		var texts = []string{}
-		texts = append(texts, h.MecabWrapper.Construct(exchangedFeatures[:index]))
-		texts = append(texts, condition.DistWord)
-		if (index + 1) <= len(exchangedFeatures) {
-			texts = append(texts, h.MecabWrapper.Construct(exchangedFeatures[index+1:]))
+/*
+* 特定敬語(現在)の変換(「ですが」など)と置換辞書の適用
+* 置換辞書(登録順)、組み込みの置換の順に最初に合致したもので置換する
+ */
+func (h *HonorificFilter) convertSpecials(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+	h.Logger.Debug("convertSpecials()")
+
+	// 置換辞書はリポジトリが読み込み済みのものを返す
+	replacements, err := h.ZundaDb.SelectReplacements()
+	if err != nil {
+		return features, newConvertError(honorificFilterName, "convertSpecials", err)
+	}
+
+	var texts = []string{}
+	var exchanged = false
+	for _, feature := range features {
+		text := feature.Word
+		replacement, ok := matchReplacement(feature, replacements, specialReplacements)
+		if ok {
+			if ce := h.Logger.Check(zap.DebugLevel, "convertSpecials(): exchange"); ce != nil {
+				ce.Write(zap.String("source", feature.Word), zap.String("target", replacement.Target))
+			}
+			text = replacement.Target
+			exchanged = true
 		}
+		texts = append(texts, text)
+	}
+	if !exchanged {
+		return features, nil
+	}
 
-		sugar.Debugf("convertSpecials(): exchange")
+	exchangedFeatures, err := h.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
+	if err != nil {
+		return features, newConvertError(honorificFilterName, "convertSpecials", err)
+	}
+	if ce := h.Logger.Check(zap.InfoLevel, "convertSpecials()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
+}
 
-		parseResult, err := h.MecabWrapper.ParseToNode(strings.Join(texts, ""))
-		if err != nil {
-			sugar.Errorf("convertSpecials() - error: %v", err)
-			return features
+/*
+* 置換辞書を順に照合し、最初に合致した置換を返す
+ */
+func matchReplacement(feature zunda_mecab.MecabFeature, dictionaries ...[]zunda_mecab.ReplacementRow) (zunda_mecab.ReplacementRow, bool) {
+	for _, replacements := range dictionaries {
+		for _, replacement := range replacements {
+			if replacement.Match(feature) {
+				return replacement, true
+			}
 		}
-		exchangedFeatures = parseResult
 	}
-
-	sugar.Infof("convertSpecials() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+	return zunda_mecab.ReplacementRow{}, false
 }
 
 /*
 * 敬語削除(現在)
  */
-func (h *HonorificFilter) removeHonorificWord(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("removeHonorificWord()")
+func (h *HonorificFilter) removeHonorificWord(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+	h.Logger.Debug("removeHonorificWord()")
 
-	conditions := getHonorificWords()
-	match, index := h.MecabWrapper.GetMatchIndex(features, conditions)
+	match, index := h.MecabWrapper.GetCompiledMatchIndex(honorificWordsMatcher, features)
 	if !match {
-		return features
+		return features, nil
 	}
 
 	texts := []string{}
@@ -296,13 +378,14 @@ func (h *HonorificFilter) removeHonorificWord(features []zunda_mecab.MecabFeatur
 	if (index + 1) <= len(features) {
 		texts = append(texts, h.MecabWrapper.Construct(features[index+1:]))
 	}
-	exchangedFeatures, err := h.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangedFeatures, err := h.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("removeHonorificWord() - error: %v", err)
-		return features
+		return features, newConvertError(honorificFilterName, "removeHonorificWord", err)
+	}
+	if ce := h.Logger.Check(zap.InfoLevel, "removeHonorificWord()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
 	}
-	sugar.Infof("removeHonorificWord() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+	return exchangedFeatures, nil
 }
 
 /*
@@ -335,15 +418,10 @@ func getPastHonorificWords() []zunda_mecab.MecabCondition {
 }
 
 /*
-* 動詞 + 敬語(現在) + ん + 敬語(過去) + た
-* ex) 彼は動きませんでした -> 彼は動かなかった
+* 動詞 + 敬語(現在) + ん + 敬語(過去) + た 条件
  */
-func (h *HonorificFilter) convertVerbBeforePastHonorificNegative(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("convertVerbBeforePastHonorificNegative()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getVerbBeforePastHonorificNegativeConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -410,46 +488,50 @@ func (h *HonorificFilter) convertVerbBeforePastHonorificNegative(features []zund
 			},
 		},
 	}
-	match, index := h.MecabWrapper.GetMatchIndex(features, conditions)
+}
+
+/*
+* 動詞 + 敬語(現在) + ん + 敬語(過去) + た
+* ex) 彼は動きませんでした -> 彼は動かなかった
+ */
+func (h *HonorificFilter) convertVerbBeforePastHonorificNegative(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+	h.Logger.Debug("convertVerbBeforePastHonorificNegative()")
+
+	match, index := h.MecabWrapper.GetCompiledMatchIndex(verbBeforePastHonorificNegativeMatcher, features)
 	if !match {
-		return features
+		return features, nil
 	}
 
 	// 条件:
 	//  + 敬語の一つ前が動詞
-	conjugationForm, err := h.ZundaDb.SelectConvertVerbConjugationTable(features[index].OriginalForm)
+	mizen, err := h.ZundaDb.SelectConjugation(features[index].OriginalForm, features[index].ConjugationType, zunda_mecab.MecabConjugationFormMizen)
 	if err != nil {
-		sugar.Errorf("convertVerbBeforePastHonorificNegative() - can not fetch verb conjugation form(%v) - %v", features[index-1].OriginalForm, err)
-		return features
+		return features, newConvertError(honorificFilterName, "convertVerbBeforePastHonorificNegative", err)
 	}
 
 	texts := []string{}
 	texts = append(texts, h.MecabWrapper.Construct(features[:index]))
-	texts = append(texts, conjugationForm.Mizen+"なかった")
-	if (index + len(conditions)) <= len(features) {
-		texts = append(texts, h.MecabWrapper.Construct(features[(index+len(conditions)):]))
+	texts = append(texts, mizen.Word+"なかった")
+	if (index + len(verbBeforePastHonorificNegativeConditions)) <= len(features) {
+		texts = append(texts, h.MecabWrapper.Construct(features[(index+len(verbBeforePastHonorificNegativeConditions)):]))
 	}
 
-	exchangedFeatures, err := h.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangedFeatures, err := h.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertVerbBeforePastHonorificNegative() - error: %v", err)
-		return features
+		return features, newConvertError(honorificFilterName, "convertVerbBeforePastHonorificNegative", err)
 	}
 
-	sugar.Infof("convertVerbBeforePastHonorificNegative() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+	if ce := h.Logger.Check(zap.InfoLevel, "convertVerbBeforePastHonorificNegative()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
 }
 
 /*
-* 動詞(サ行変格活用) + 敬語(過去)の変換
-* 「する」が五段活用と認識される為、別途変換を実施
+* 動詞(サ行変格活用) + 敬語(過去)の変換 条件
  */
-func (h *HonorificFilter) convertSahenVerbBeforePastHorific(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-
-	sugar.Debug("convertSahenVerbBeforePastHorific()")
-	conditions := []zunda_mecab.MecabCondition{
+func getSahenVerbBeforePastHorificConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -465,27 +547,7 @@ func (h *HonorificFilter) convertSahenVerbBeforePastHorific(features []zunda_mec
 				},
 			},
 		},
-		{
-			ConditionType: zunda_mecab.MecabConditionTypeOne,
-			Features: []zunda_mecab.MecabConditionFeature{
-				{
-					CheckWord:         true,
-					Word:              "でし",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeAuxiliaryVerb,
-					CheckOriginalForm: true,
-					OriginalForm:      "です",
-				},
-				{
-					CheckWord:         true,
-					Word:              "まし",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeAuxiliaryVerb,
-					CheckOriginalForm: true,
-					OriginalForm:      "ます",
-				},
-			},
-		},
+		getPastHonorificWords()[0],
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -500,35 +562,41 @@ func (h *HonorificFilter) convertSahenVerbBeforePastHorific(features []zunda_mec
 			},
 		},
 	}
+}
+
+/*
+* 動詞(サ行変格活用) + 敬語(過去)の変換
+* 「する」が五段活用と認識される為、別途変換を実施
+ */
+func (h *HonorificFilter) convertSahenVerbBeforePastHorific(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
 
-	match, index := h.MecabWrapper.GetMatchIndex(features, conditions)
+	h.Logger.Debug("convertSahenVerbBeforePastHorific()")
+
+	match, index := h.MecabWrapper.GetCompiledMatchIndex(sahenVerbBeforePastHorificMatcher, features)
 	if !match {
-		return features
+		return features, nil
 	}
 
 	texts := []string{}
 	texts = append(texts, h.MecabWrapper.Construct(features[:index+1]))
 	texts = append(texts, h.MecabWrapper.Construct(features[index+2:]))
 
-	exchangedFeatures, err := h.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangedFeatures, err := h.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertVerbBeforePastHonorificNegative() - error: %v", err)
-		return features
+		return features, newConvertError(honorificFilterName, "convertSahenVerbBeforePastHorific", err)
 	}
 
-	sugar.Infof("convertSahenVerbBeforePastHorific() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+	if ce := h.Logger.Check(zap.InfoLevel, "convertSahenVerbBeforePastHorific()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
 }
 
 /*
-* 名詞 + 敬語(過去)の変換
+* 名詞 + 敬語(過去)の変換 条件
  */
-func (h *HonorificFilter) convertNounBeforePastHorific(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("convertNounBeforePastHorific()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getNounBeforePastHorificConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -544,27 +612,7 @@ func (h *HonorificFilter) convertNounBeforePastHorific(features []zunda_mecab.Me
 				},
 			},
 		},
-		{
-			ConditionType: zunda_mecab.MecabConditionTypeOne,
-			Features: []zunda_mecab.MecabConditionFeature{
-				{
-					CheckWord:         true,
-					Word:              "でし",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeAuxiliaryVerb,
-					CheckOriginalForm: true,
-					OriginalForm:      "です",
-				},
-				{
-					CheckWord:         true,
-					Word:              "まし",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeAuxiliaryVerb,
-					CheckOriginalForm: true,
-					OriginalForm:      "ます",
-				},
-			},
-		},
+		getPastHonorificWords()[0],
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -579,10 +627,17 @@ func (h *HonorificFilter) convertNounBeforePastHorific(features []zunda_mecab.Me
 			},
 		},
 	}
+}
+
+/*
+* 名詞 + 敬語(過去)の変換
+ */
+func (h *HonorificFilter) convertNounBeforePastHorific(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+	h.Logger.Debug("convertNounBeforePastHorific()")
 
-	match, index := h.MecabWrapper.GetMatchIndex(features, conditions)
+	match, index := h.MecabWrapper.GetCompiledMatchIndex(nounBeforePastHorificMatcher, features)
 	if !match {
-		return features
+		return features, nil
 	}
 
 	// 条件
@@ -595,95 +650,100 @@ func (h *HonorificFilter) convertNounBeforePastHorific(features []zunda_mecab.Me
 		texts = append(texts, h.MecabWrapper.Construct(features[index+3:]))
 	}
 
-	exchangedFeatures, err := h.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangedFeatures, err := h.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertNounBeforePastHorific() - error: %v", err)
-		return features
+		return features, newConvertError(honorificFilterName, "convertNounBeforePastHorific", err)
 	}
 
-	sugar.Infof("convertNounBeforePastHorific() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+	if ce := h.Logger.Check(zap.InfoLevel, "convertNounBeforePastHorific()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
 }
 
 /*
 * 動詞(撥音便) + 敬語(過去)の変換
  */
-func (h *HonorificFilter) convertVerbBeforePastHorificHatsuOnbin(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("convertVerbBeforePastHorificHatsuOnbin()")
+func (h *HonorificFilter) convertVerbBeforePastHorificHatsuOnbin(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+	h.Logger.Debug("convertVerbBeforePastHorificHatsuOnbin()")
 	// 条件
 	// 敬語の直前が動詞
 	// 敬語の直後が「た」
 	// 動詞の活用が五段・ナ行,五段・バ行,五段・マ行
-	exchangedFeatures := h.convertVerbBeforePastHorificOnbin(features,
+	exchangedFeatures, err := h.convertVerbBeforePastHorificOnbin(ctx, features,
 		[]zunda_mecab.MecabConjugationType{
 			zunda_mecab.MecabConjugationTypeGodanNa,
 			zunda_mecab.MecabConjugationTypeGodanBa,
 			zunda_mecab.MecabConjugationTypeGodanMa,
 		},
-		"た",
-		"んだ")
-	sugar.Infof("convertVerbBeforePastHorificHatsuOnbin() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+		verbBeforePastHorificOnbinMatcher)
+	if err != nil {
+		return features, err
+	}
+	if ce := h.Logger.Check(zap.InfoLevel, "convertVerbBeforePastHorificHatsuOnbin()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
 }
 
 /*
 * 動詞(イ音便) + 敬語(過去)の変換
  */
-func (h *HonorificFilter) convertVerbBeforePastHorificIOnbin(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("convertVerbBeforePastHorificIOnbin()")
+func (h *HonorificFilter) convertVerbBeforePastHorificIOnbin(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+	h.Logger.Debug("convertVerbBeforePastHorificIOnbin()")
 	// 条件
 	// 敬語の直前が動詞
 	// 敬語の直後が「た」
 	// 動詞の活用が五段・カ行イ音便, 五段・ガ行
-	exchangedFeatures := h.convertVerbBeforePastHorificOnbin(features,
+	exchangedFeatures, err := h.convertVerbBeforePastHorificOnbin(ctx, features,
 		[]zunda_mecab.MecabConjugationType{
 			zunda_mecab.MecabConjugationTypeGodanKaIOnbin,
 			zunda_mecab.MecabConjugationTypeGodanGa,
 		},
-		"た",
-		"いた")
-	sugar.Infof("convertVerbBeforePastHorificIOnbin() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+		verbBeforePastHorificOnbinMatcher)
+	if err != nil {
+		return features, err
+	}
+	if ce := h.Logger.Check(zap.InfoLevel, "convertVerbBeforePastHorificIOnbin()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
 }
 
 /*
 * 動詞(促音便) + 敬語(過去)の変換
  */
-func (h *HonorificFilter) convertVerbBeforePastHorificSokuOnbin(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("convertVerbBeforePastHorificSokuOnbin()")
+func (h *HonorificFilter) convertVerbBeforePastHorificSokuOnbin(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+	h.Logger.Debug("convertVerbBeforePastHorificSokuOnbin()")
 
 	// 条件
 	// 敬語の直前が動詞
 	// 敬語の直後が「た」
-	// 動詞の活用が五段・タ行, 五段・ワ行促音便, 五段・ラ行
-	exchangedFeatures := h.convertVerbBeforePastHorificOnbin(features,
+	// 動詞の活用が五段・タ行, 五段・ワ行促音便, 五段・ラ行, 五段・カ行促音便
+	exchangedFeatures, err := h.convertVerbBeforePastHorificOnbin(ctx, features,
 		[]zunda_mecab.MecabConjugationType{
 			zunda_mecab.MecabConjugationTypeGodanTa,
 			zunda_mecab.MecabConjugationTypeGodanWaSokuOnbin,
 			zunda_mecab.MecabConjugationTypeGodanRa,
+			zunda_mecab.MecabConjugationTypeGodanKaSokuOnbin,
+			zunda_mecab.MecabConjugationTypeGodanKaYuku,
 		},
-		"た",
-		"った")
-	sugar.Infof("convertVerbBeforePastHorificSokuOnbin() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+		verbBeforePastHorificOnbinMatcher)
+	if err != nil {
+		return features, err
+	}
+	if ce := h.Logger.Check(zap.InfoLevel, "convertVerbBeforePastHorificSokuOnbin()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
 
 }
 
 /*
-* 音便+敬語(過去)の対応
+* 音便+敬語(過去) 条件
  */
-func (h *HonorificFilter) convertVerbBeforePastHorificOnbin(features []zunda_mecab.MecabFeature, conjugationTypes []zunda_mecab.MecabConjugationType, particleAfterHonorific string, replacedAfterText string) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-
-	sugar.Debug("convertVerbBeforePastHorificOnbin()")
-	conditions := []zunda_mecab.MecabCondition{
+func getVerbBeforePastHorificOnbinConditions(particleAfterHonorific string) []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -697,27 +757,7 @@ func (h *HonorificFilter) convertVerbBeforePastHorificOnbin(features []zunda_mec
 				},
 			},
 		},
-		{
-			ConditionType: zunda_mecab.MecabConditionTypeOne,
-			Features: []zunda_mecab.MecabConditionFeature{
-				{
-					CheckWord:         true,
-					Word:              "でし",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeAuxiliaryVerb,
-					CheckOriginalForm: true,
-					OriginalForm:      "です",
-				},
-				{
-					CheckWord:         true,
-					Word:              "まし",
-					CheckWordType:     true,
-					WordType:          zunda_mecab.MecabWordTypeAuxiliaryVerb,
-					CheckOriginalForm: true,
-					OriginalForm:      "ます",
-				},
-			},
-		},
+		getPastHonorificWords()[0],
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -732,33 +772,42 @@ func (h *HonorificFilter) convertVerbBeforePastHorificOnbin(features []zunda_mec
 			},
 		},
 	}
-	match, index := h.MecabWrapper.GetMatchIndex(features, conditions)
+}
+
+/*
+* 音便+敬語(過去)の対応
+* 動詞を連用タ接続へ活用し、過去の助動詞(「た」または「だ」)を続ける
+* ex) 書きました -> 書い + た
+* matcher: 動詞 + 敬語(過去) + 過去の助動詞の条件(verbBeforePastHorificOnbinMatcher)
+ */
+func (h *HonorificFilter) convertVerbBeforePastHorificOnbin(ctx context.Context, features []zunda_mecab.MecabFeature, conjugationTypes []zunda_mecab.MecabConjugationType, matcher *zunda_mecab.MecabMatcher) ([]zunda_mecab.MecabFeature, error) {
+
+	h.Logger.Debug("convertVerbBeforePastHorificOnbin()")
+	match, index := h.MecabWrapper.GetCompiledMatchIndex(matcher, features)
 	if !match {
-		sugar.Debug("convertVerbBeforePastHorificOnbin() - has not past honorific words")
-		return features
+		h.Logger.Debug("convertVerbBeforePastHorificOnbin() - has not past honorific words")
+		return features, nil
 	}
 
-	verbFeatures, err := h.MecabWrapper.ParseToNodeWithoutEos(features[index].OriginalForm)
-	if err != nil {
-		sugar.Errorf("convertVerbBeforePastHorificOnbin() - can not parse %s, %v", err)
-		return features
-	}
-	for _, feature := range verbFeatures {
-		sugar.Debug(feature.String())
-	}
+	verbFeature := features[index]
 	var isExchangeConjugationType = false
 	for _, conjugationType := range conjugationTypes {
-		if verbFeatures[0].ConjugationType == conjugationType {
+		if verbFeature.ConjugationType == conjugationType {
 			isExchangeConjugationType = true
 			break
 		}
 	}
 	if !isExchangeConjugationType {
-		sugar.Debugf("convertVerbBeforePastHorificOnbin() - invalid verb conjugation type(%s - %s)", verbFeatures[0].Word, verbFeatures[0].ConjugationType.String())
-		return features
+		if ce := h.Logger.Check(zap.DebugLevel, "convertVerbBeforePastHorificOnbin() - invalid verb conjugation type"); ce != nil {
+			ce.Write(zap.String("word", verbFeature.Word), zap.Stringer("conjugationType", verbFeature.ConjugationType))
+		}
+		return features, nil
 	}
-	slice := []rune(features[index].OriginalForm)
-	replacedVerbText := string(slice[0:len(slice)-1]) + replacedAfterText
+	renyou, err := h.ZundaDb.SelectConjugation(verbFeature.OriginalForm, verbFeature.ConjugationType, zunda_mecab.MecabConjugationFormRenyouTaSetsuzoku)
+	if err != nil {
+		return features, newConvertError(honorificFilterName, "convertVerbBeforePastHorificOnbin", err)
+	}
+	replacedVerbText := renyou.Word + getPastAuxiliaryWord(verbFeature.ConjugationType)
 
 	texts := []string{}
 	texts = append(texts, h.MecabWrapper.Construct(features[:index]))
@@ -767,28 +816,42 @@ func (h *HonorificFilter) convertVerbBeforePastHorificOnbin(features []zunda_mec
 		texts = append(texts, h.MecabWrapper.Construct(features[index+3:]))
 	}
 
-	exchangedFeatures, err := h.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangedFeatures, err := h.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertVerbBeforePastHorificOnbin() - error: %v", err)
-		return features
+		return features, newConvertError(honorificFilterName, "convertVerbBeforePastHorificOnbin", err)
+	}
+	if ce := h.Logger.Check(zap.InfoLevel, "convertVerbBeforePastHorificOnbin()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
+	}
+	return exchangedFeatures, nil
+}
+
+/*
+* 連用タ接続に続く過去の助動詞
+* 撥音便(ナ行・バ行・マ行)とガ行のイ音便は「だ」になる
+ */
+func getPastAuxiliaryWord(conjugationType zunda_mecab.MecabConjugationType) string {
+	switch conjugationType {
+	case zunda_mecab.MecabConjugationTypeGodanNa,
+		zunda_mecab.MecabConjugationTypeGodanBa,
+		zunda_mecab.MecabConjugationTypeGodanMa,
+		zunda_mecab.MecabConjugationTypeGodanGa:
+		return "だ"
+	default:
+		return "た"
 	}
-	sugar.Infof("convertVerbBeforePastHorificOnbin() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
 }
 
 /*
 * 敬語削除(過去)
  */
-func (h *HonorificFilter) removePastHonorificWord(features []zunda_mecab.MecabFeature) []zunda_mecab.MecabFeature {
-	defer h.Logger.Sync()
-	sugar := h.Logger.Sugar()
-	sugar.Debug("removePastHonorificWord()")
+func (h *HonorificFilter) removePastHonorificWord(ctx context.Context, features []zunda_mecab.MecabFeature) ([]zunda_mecab.MecabFeature, error) {
+	h.Logger.Debug("removePastHonorificWord()")
 
-	conditions := getPastHonorificWords()
-	match, index := h.MecabWrapper.GetMatchIndex(features, conditions)
+	match, index := h.MecabWrapper.GetCompiledMatchIndex(pastHonorificWordsMatcher, features)
 	if !match {
-		sugar.Debug("removePastHonorificWord() - has not past honorific words")
-		return features
+		h.Logger.Debug("removePastHonorificWord() - has not past honorific words")
+		return features, nil
 	}
 
 	texts := []string{}
@@ -796,11 +859,12 @@ func (h *HonorificFilter) removePastHonorificWord(features []zunda_mecab.MecabFe
 	if (index + 1) <= len(features) {
 		texts = append(texts, h.MecabWrapper.Construct(features[index+1:]))
 	}
-	exchangedFeatures, err := h.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangedFeatures, err := h.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("removePastHonorificWord() - error: %v", err)
-		return features
+		return features, newConvertError(honorificFilterName, "removePastHonorificWord", err)
+	}
+	if ce := h.Logger.Check(zap.InfoLevel, "removePastHonorificWord()"); ce != nil {
+		ce.Write(zap.String("converted", h.MecabWrapper.Construct(exchangedFeatures)))
 	}
-	sugar.Infof("removePastHonorificWord() - converted: %s", h.MecabWrapper.Construct(exchangedFeatures))
-	return exchangedFeatures
+	return exchangedFeatures, nil
 }
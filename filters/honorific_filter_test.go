@@ -7,20 +7,6 @@ import (
 	"zundafilter/zunda_mecab"
 )
 
-type TestZundaDbAccessor struct{}
-
-func (t TestZundaDbAccessor) SelectConvertVerbConjugationTable(baseWord string) (zunda_mecab.ConvertVerbConjugationRow, error) {
-	switch baseWord {
-	case "渡す":
-		return zunda_mecab.ConvertVerbConjugationRow{
-			BaseWord: "渡す",
-			Mizen:    "渡さ",
-		}, nil
-	default:
-		return zunda_mecab.ConvertVerbConjugationRow{}, nil
-	}
-}
-
 func getTestLogger() *zap.Logger {
 	level := zap.NewAtomicLevel()
 	level.SetLevel(zapcore.InfoLevel)
@@ -133,6 +119,11 @@ func TestHonorificFilter(t *testing.T) {
 			text:   "昨日読みました",
 			expect: "昨日読んだ",
 		},
+		{
+			name:   "動詞-ガ行イ音便変化+敬語(まし)+過去",
+			text:   "プールで泳ぎました",
+			expect: "プールで泳いだ",
+		},
 		{
 			name:   "名詞+敬語(でし)+過去",
 			text:   "体調不良でした",
@@ -164,7 +155,7 @@ func TestHonorificFilter(t *testing.T) {
 		testCase := testCase
 		t.Run(testCase.name, func(t *testing.T) {
 			// t.Parallel()
-			zundaDbAccessor := TestZundaDbAccessor{}
+			zundaDbAccessor := zunda_mecab.ConjugatorRepository{}
 			mecabWrapper := zunda_mecab.MecabWrapper{
 				Logger: getTestLogger(),
 			}
@@ -1,80 +1,170 @@
 package filters
 
 import (
+	"context"
 	"go.uber.org/zap"
 	"strings"
 	"zundafilter/zunda_mecab"
 )
 
+const moodFilterName = "MoodFilter"
+
 type MoodFilter struct {
-	MecabWrapper *zunda_mecab.MecabWrapper
-	Logger       *zap.Logger
+	MecabWrapper  *zunda_mecab.MecabWrapper
+	Logger        *zap.Logger
+	DisabledRules []string // 適用しない規則名(moodRulesのName)
+	Stats         *Stats
 }
 type MoodConvertResult struct {
 	Features []zunda_mecab.MecabFeature
 	Parsed   bool
+	Err      error // 変換規則の実行時エラー。ParsedはfalseでFeaturesは変換前のまま
+}
+
+/*
+* ムードの変換ルール
+* 並び順が優先度(先頭から順に試し、最初に変換できたルールを採用する)
+ */
+type moodRule struct {
+	Name       string
+	Conditions []zunda_mecab.MecabCondition
+	Convert    func(*MoodFilter, context.Context, []zunda_mecab.MecabFeature, int) MoodConvertResult
+}
+
+var (
+	moodRules   = getMoodRules()
+	moodMatcher = compileMoodMatcher(moodRules)
+)
+
+// ムード後の文字列(記号{0..*} + EOS等)の条件(起動時に一度だけコンパイルする)
+var (
+	confirmationMoodAfterTextMatcher = zunda_mecab.CompileMecabMatcher(getConfirmationMoodConditions()[2:])
+	affirmativeMoodAfterTextMatcher  = zunda_mecab.CompileMecabMatcher(getAffirmativeMoodConditions()[2:])
+	confidenceMoodAfterTextMatcher   = zunda_mecab.CompileMecabMatcher(getConfidenceMoodConditions()[2:])
+	invitationMoodAfterTextMatcher   = zunda_mecab.CompileMecabMatcher(getInvitationMoodConditions()[3:])
+	requestMoodAfterTextMatcher      = zunda_mecab.CompileMecabMatcher(getRequestMoodConditions()[3:])
+	undecisionMoodAfterTextMatcher   = zunda_mecab.CompileMecabMatcher(getUndecisionMoodConditions()[2:])
+	questionMoodAfterTextMatcher     = zunda_mecab.CompileMecabMatcher(getQuestionMoodConditions()[1:])
+	orderMoodAfterTextMatcher        = zunda_mecab.CompileMecabMatcher(getOrderMoodConditions()[2:])
+	orderTaigenMoodAfterTextMatcher  = zunda_mecab.CompileMecabMatcher(getOrderTaigenMoodConditions()[1:])
+)
+
+func getMoodRules() []moodRule {
+	return []moodRule{
+		{Name: "nai_adjective", Conditions: getNaiAdjectiveMoodConditions(), Convert: (*MoodFilter).convertNaiAdjectiveMood},
+		{Name: "past", Conditions: getPastMoodConditions(), Convert: (*MoodFilter).convertPastMood},
+		{Name: "prohibition", Conditions: getProhibitionMoodConditions(), Convert: (*MoodFilter).convertProhibitionMood},
+		{Name: "desire", Conditions: getDesireMoodConditions(), Convert: (*MoodFilter).convertDesireMood},
+		{Name: "allow", Conditions: getAllowMoodConditions(), Convert: (*MoodFilter).convertAllowMood},
+		{Name: "conclusion_conversation", Conditions: getConclusionConversationMoodConditions(), Convert: (*MoodFilter).convertConclusionConversationMood},
+		{Name: "invitation", Conditions: getInvitationMoodConditions(), Convert: (*MoodFilter).convertInvitationMood},
+		{Name: "order_taigen", Conditions: getOrderTaigenMoodConditions(), Convert: (*MoodFilter).convertOrderTaigenMood},
+		{Name: "order", Conditions: getOrderMoodConditions(), Convert: (*MoodFilter).convertOrderMood},
+		{Name: "confirmation", Conditions: getConfirmationMoodConditions(), Convert: (*MoodFilter).convertConfirmationMood},
+		{Name: "question_intention", Conditions: getQuestionIntentionMoodConditions(), Convert: (*MoodFilter).convertQuestionIntentionMood},
+		{Name: "question", Conditions: getQuestionMoodConditions(), Convert: (*MoodFilter).convertQuestionMood},
+		{Name: "undecision", Conditions: getUndecisionMoodConditions(), Convert: (*MoodFilter).convertUndecisionMood},
+		{Name: "agreement", Conditions: getAgreementMoodConditions(), Convert: (*MoodFilter).convertAgreementMood},
+		{Name: "request", Conditions: getRequestMoodConditions(), Convert: (*MoodFilter).convertRequestMood},
+		{Name: "confidence", Conditions: getConfidenceMoodConditions(), Convert: (*MoodFilter).convertConfidenceMood},
+		{Name: "intention2", Conditions: getIntention2MoodConditions(), Convert: (*MoodFilter).convertIntention2Mood},
+		{Name: "intention", Conditions: getIntentionMoodConditions(), Convert: (*MoodFilter).convertIntentionMood},
+		{Name: "guess", Conditions: getGuessMoodConditions(), Convert: (*MoodFilter).convertGuessMood},
+		{Name: "possibility", Conditions: getPossibilityMoodConditions(), Convert: (*MoodFilter).convertPossibilityMood},
+		{Name: "anxiety", Conditions: getAnxietyMoodConditions(), Convert: (*MoodFilter).convertAnxietyMood},
+		{Name: "affirmative", Conditions: getAffirmativeMoodConditions(), Convert: (*MoodFilter).convertAffirmativeMood},
+	}
+}
+
+var moodFilterDefinition = FilterDefinition{
+	Name:  FilterMood,
+	Rules: moodRuleNames(),
+	New: func(z *ZundaFilter, disabledRules []string) Converter {
+		return &MoodFilter{
+			MecabWrapper:  z.MecabWrapper,
+			Logger:        z.Logger,
+			DisabledRules: disabledRules,
+			Stats:         z.Stats,
+		}
+	},
+}
+
+func moodRuleNames() []string {
+	names := []string{}
+	for _, rule := range moodRules {
+		names = append(names, rule.Name)
+	}
+	return names
+}
+
+func compileMoodMatcher(rules []moodRule) *zunda_mecab.MecabMatcher {
+	conditions := [][]zunda_mecab.MecabCondition{}
+	for _, rule := range rules {
+		conditions = append(conditions, rule.Conditions)
+	}
+	return zunda_mecab.CompileMecabMatcher(conditions...)
 }
 
 func (m *MoodFilter) Convert(text string) (string, error) {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
+	return m.ConvertContext(context.Background(), text)
+}
 
-	features, err := m.MecabWrapper.ParseToNode(text)
+func (m *MoodFilter) ConvertContext(ctx context.Context, text string) (string, error) {
+	result, err := m.ConvertEdits(ctx, text)
+	return result.Text, err
+}
+
+func (m *MoodFilter) ConvertEdits(ctx context.Context, text string) (ConvertResult, error) {
+
+	features, err := m.MecabWrapper.ParseToNodeContext(ctx, text)
 	if err != nil {
-		return "", nil
+		return ConvertResult{}, newConvertError(moodFilterName, "Convert", err)
 	}
 
 	// パース結果の出力
 	for _, feature := range features {
-		sugar.Debug(feature.String())
-	}
-
-	converters := []func([]zunda_mecab.MecabFeature) MoodConvertResult{
-		m.convertNaiAdjectiveMood,
-		m.convertPastMood,
-		m.convertProhibitionMood,
-		m.convertDesireMood,
-		m.convertAllowMood,
-		m.convertConclusionConversationMood,
-		m.convertInvitationMood,
-		m.convertOrderTaigenMood,
-		m.convertOrderMood,
-		m.convertConfirmationMood,
-		m.convertQuestionIntentionMood,
-		m.convertQuestionMood,
-		m.convertUndecisionMood,
-		m.convertAgreementMood,
-		m.convertRequestMood,
-		m.convertConfidenceMood,
-		m.convertIntention2Mood,
-		m.convertIntentionMood,
-		m.convertGuessMood,
-		m.convertPossibilityMood,
-		m.convertAnxietyMood,
-		m.convertAffirmativeMood,
-	}
-	for _, converter := range converters {
-		MoodConvertResult := converter(features)
+		if ce := m.Logger.Check(zap.DebugLevel, "feature"); ce != nil {
+			ce.Write(zap.String("feature", feature.String()))
+		}
+	}
+
+	// 全ムードの条件を1回の走査で照合し、優先度順に変換を試す
+	indexes := m.MecabWrapper.MatchAll(moodMatcher, features)
+	for i, rule := range moodRules {
+		if indexes[i] < 0 || containsName(m.DisabledRules, rule.Name) {
+			continue
+		}
+		if ce := m.Logger.Check(zap.DebugLevel, "MoodFilter#Convert() - match"); ce != nil {
+			ce.Write(zap.String("rule", rule.Name), zap.Int("index", indexes[i]))
+		}
+		if err := ctx.Err(); err != nil {
+			return ConvertResult{}, err
+		}
+		MoodConvertResult := rule.Convert(m, ctx, features, indexes[i])
+		if MoodConvertResult.Err != nil {
+			if err := skipRuleFailure(m.Logger, MoodConvertResult.Err); err != nil {
+				return ConvertResult{}, err
+			}
+			continue
+		}
 		if MoodConvertResult.Parsed {
-			return m.MecabWrapper.Construct(MoodConvertResult.Features), nil
+			m.Stats.addRuleHit(FilterMood, rule.Name)
+			editor := newFeatureEditor(FilterMood, text, features)
+			editor.apply(rule.Name, MoodConvertResult.Features)
+			convertedText, edits := editor.result()
+			return ConvertResult{Original: text, Text: convertedText, Edits: edits}, nil
 		}
 	}
 
-	return text, nil
+	return ConvertResult{Original: text, Text: text, Edits: []Edit{}}, nil
 
 }
 
 /*
-* 確認のムード
-* 条件:  "だろう" + 記号{0..*}
-* ex) 昨日、一緒に腹筋しただろう。
+* 確認のムード 条件
  */
-func (m *MoodFilter) convertConfirmationMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertConfirmationMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getConfirmationMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -132,46 +222,42 @@ func (m *MoodFilter) convertConfirmationMood(features []zunda_mecab.MecabFeature
 			},
 		},
 	}
+}
 
-	match, exchangeIndex := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertConfirmationMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 確認のムード
+* 条件:  "だろう" + 記号{0..*}
+* ex) 昨日、一緒に腹筋しただろう。
+ */
+func (m *MoodFilter) convertConfirmationMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertConfirmationMood()")
 
 	// ムード後の文字列
-	afterTextCondition := conditions[2:]
-	afterTextMatch, afterTextIndex := m.MecabWrapper.GetMatchIndex(features, afterTextCondition)
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(confirmationMoodAfterTextMatcher, features)
 
 	// 記号{0..*}+EOS -> なのだ+記号{0..*}+EOS
 	texts := []string{}
-	texts = append(texts, m.MecabWrapper.Construct(features[:exchangeIndex+2]))
+	texts = append(texts, m.MecabWrapper.Construct(features[:index+2]))
 	texts = append(texts, "なのだ")
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertConfirmationMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertConfirmationMood", err)}
 	}
 
-	sugar.Infof("convertConfirmationMood() - converted: %s", m.MecabWrapper.Construct(features))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertConfirmationMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(features)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 断定のムード
-* 条件: 名詞 + "だ" + φ
-*   + φ <- [記号 | $ | φ]
-* ex) これが正義だ
+* 断定のムード 条件
  */
-func (m *MoodFilter) convertAffirmativeMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertAffirmativeMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getAffirmativeMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -229,42 +315,41 @@ func (m *MoodFilter) convertAffirmativeMood(features []zunda_mecab.MecabFeature)
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertAffirmativeMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 断定のムード
+* 条件: 名詞 + "だ" + φ
+*   + φ <- [記号 | $ | φ]
+* ex) これが正義だ
+ */
+func (m *MoodFilter) convertAffirmativeMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertAffirmativeMood()")
 
 	// だ+記号{0..*}+EOS -> なのだ+記号{0..*}+EOS
 	texts := []string{}
 	texts = append(texts, m.MecabWrapper.Construct(features[:index+1])) // 名詞まで含める
 	texts = append(texts, "なのだ")
-	afterTextMatch, afterTextIndex:= m.MecabWrapper.GetMatchIndex(features, conditions[2:])
-	if afterTextMatch{
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(affirmativeMoodAfterTextMatcher, features)
+	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertAffirmativeMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertAffirmativeMood", err)}
 	}
 
-	sugar.Infof("convertAffirmativeMood() - converted: %s", m.MecabWrapper.Construct(features))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertAffirmativeMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(features)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 可能性のムード
-* 条件: "かもしれない" + 記号{0..*}
-* ex) 僕は腹筋できるかもしれない。
+* 可能性のムード 条件
  */
-func (m *MoodFilter) convertPossibilityMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertPossibilityMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getPossibilityMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -336,12 +421,15 @@ func (m *MoodFilter) convertPossibilityMood(features []zunda_mecab.MecabFeature)
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertPossibilityMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 可能性のムード
+* 条件: "かもしれない" + 記号{0..*}
+* ex) 僕は腹筋できるかもしれない。
+ */
+func (m *MoodFilter) convertPossibilityMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertPossibilityMood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 3) < len(features)
@@ -353,27 +441,22 @@ func (m *MoodFilter) convertPossibilityMood(features []zunda_mecab.MecabFeature)
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+3:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertPossibilityMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertPossibilityMood", err)}
 	}
 
-	sugar.Infof("convertPossibilityMood() - converted: %s", m.MecabWrapper.Construct(features))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertPossibilityMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(features)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 推量のムード
-* 条件: "らしい" + 記号{0..*} + EOS
-* ex) 僕は腹筋するらしい。
+* 推量のムード 条件
  */
-func (m *MoodFilter) convertGuessMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertGuessMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getGuessMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -417,12 +500,15 @@ func (m *MoodFilter) convertGuessMood(features []zunda_mecab.MecabFeature) MoodC
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertGuessMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 推量のムード
+* 条件: "らしい" + 記号{0..*} + EOS
+* ex) 僕は腹筋するらしい。
+ */
+func (m *MoodFilter) convertGuessMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertGuessMood()")
 
 	// 記号{0..*}+EOS -> のだ+記号{0..*}+EOS
 	texts := []string{}
@@ -431,27 +517,22 @@ func (m *MoodFilter) convertGuessMood(features []zunda_mecab.MecabFeature) MoodC
 	if (index + 1) <= len(features) {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+1:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertGuessMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertGuessMood", err)}
 	}
 
-	sugar.Infof("convertGuessMood() - converted: %s", m.MecabWrapper.Construct(features))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertGuessMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(features)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 意志のムード
-* 条件: 動詞(基本形) + 記号{0..*} + EOS
-* ex) 僕は腹筋する
+* 意志のムード 条件
  */
-func (m *MoodFilter) convertIntentionMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertIntentionMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getIntentionMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -501,12 +582,15 @@ func (m *MoodFilter) convertIntentionMood(features []zunda_mecab.MecabFeature) M
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertIntentionMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 意志のムード
+* 条件: 動詞(基本形) + 記号{0..*} + EOS
+* ex) 僕は腹筋する
+ */
+func (m *MoodFilter) convertIntentionMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertIntentionMood()")
 
 	// 記号{0..*}+EOS -> のだ+記号{0..*}+EOS
 	texts := []string{}
@@ -515,27 +599,22 @@ func (m *MoodFilter) convertIntentionMood(features []zunda_mecab.MecabFeature) M
 	if (index + 1) <= len(features) {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+1:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertIntentionMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertIntentionMood", err)}
 	}
 
-	sugar.Infof("convertIntentionMood() - converted: %s", m.MecabWrapper.Construct(features))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertIntentionMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(features)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 確信のムード
-* 条件: はず + だ{0..1} + 記号{0..*} + EOS
-* ex) 僕は腹筋する
+* 確信のムード 条件
  */
-func (m *MoodFilter) convertConfidenceMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertConfidenceMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getConfidenceMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -601,20 +680,18 @@ func (m *MoodFilter) convertConfidenceMood(features []zunda_mecab.MecabFeature)
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertConfidenceMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 確信のムード
+* 条件: はず + だ{0..1} + 記号{0..*} + EOS
+* ex) 僕は腹筋する
+ */
+func (m *MoodFilter) convertConfidenceMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertConfidenceMood()")
 
 	// ムード後の文字列
-	unExchangeConditions := conditions[2:]
-	afterTextMatch, afterTextIndex := m.MecabWrapper.GetMatchIndex(features, unExchangeConditions)
-	if !match {
-		sugar.Debug("convertConfidenceMood() - after text condition not exists")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(confidenceMoodAfterTextMatcher, features)
 
 	// 記号{0..*}+EOS -> のだ+記号{0..*}+EOS
 	texts := []string{}
@@ -623,29 +700,22 @@ func (m *MoodFilter) convertConfidenceMood(features []zunda_mecab.MecabFeature)
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertConfidenceMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertConfidenceMood", err)}
 	}
 
-	sugar.Infof("convertConfidenceMood() - converted: %s", m.MecabWrapper.Construct(features))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertConfidenceMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(features)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 勧誘のムード
-* 条件: 動詞(連用形) + ましょ + う + 記号{0..*} + EOS
-* ex) 僕は腹筋する
+* 勧誘のムード 条件
  */
-func (m *MoodFilter) convertInvitationMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertInvitationMood()")
-	// し      動詞,自立,*,*,サ変・スル,連用形,する,シ,シ
-	// ましょ  助動詞,*,*,*,特殊・マス,未然ウ接続,ます,マショ,マショ
-	// う      助動詞,*,*,*,不変化型,基本形,う,ウ,ウ
-	conditions := []zunda_mecab.MecabCondition{
+func getInvitationMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -727,45 +797,45 @@ func (m *MoodFilter) convertInvitationMood(features []zunda_mecab.MecabFeature)
 			},
 		},
 	}
+}
 
-	match, conditionIndex := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertInvitationMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 勧誘のムード
+* 条件: 動詞(連用形) + ましょ + う + 記号{0..*} + EOS
+* ex) 僕は腹筋する
+ */
+func (m *MoodFilter) convertInvitationMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertInvitationMood()")
+	// し      動詞,自立,*,*,サ変・スル,連用形,する,シ,シ
+	// ましょ  助動詞,*,*,*,特殊・マス,未然ウ接続,ます,マショ,マショ
+	// う      助動詞,*,*,*,不変化型,基本形,う,ウ,ウ
 
 	// ムード後の文字列
-	afterTextConditions := conditions[3:]
-	afterTextMatch, afterTextIndex := m.MecabWrapper.GetMatchIndex(features, afterTextConditions)
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(invitationMoodAfterTextMatcher, features)
 
 	// 記号{0..*}+EOS -> なのだ+記号{0..*}+EOS
 	texts := []string{}
-	texts = append(texts, m.MecabWrapper.Construct(features[:conditionIndex+3]))
+	texts = append(texts, m.MecabWrapper.Construct(features[:index+3]))
 	texts = append(texts, "なのだ")
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertInvitationMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertInvitationMood", err)}
 	}
 
-	sugar.Infof("convertInvitationMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertInvitationMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 依頼のムード
-* 条件: 動詞 + て + ください + 記号{0..*} + EOS
-* ex) 一緒に腹筋してください
+* 依頼のムード 条件
  */
-func (m *MoodFilter) convertRequestMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertRequestMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getRequestMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -847,49 +917,53 @@ func (m *MoodFilter) convertRequestMood(features []zunda_mecab.MecabFeature) Moo
 			},
 		},
 	}
+}
 
-	match, exchangeIndex := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertRequestMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
+/*
+* 依頼のムード
+* 条件: 動詞 + て + ください + 記号{0..*} + EOS
+* ex) 一緒に腹筋してください
+ */
+func (m *MoodFilter) convertRequestMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertRequestMood()")
+
+	if ce := m.Logger.Check(zap.DebugLevel, "convertRequestMood() - mood head feature"); ce != nil {
+		ce.Write(zap.Int("index", index), zap.String("feature", features[index].String()))
 	}
-	sugar.Debugf("convertRequestMood() - mood head feature(%d): %s", exchangeIndex, features[exchangeIndex].String())
 
 	// ムード後の文字列
-	unExchangeConditions := conditions[3:]
-	afterTextMatch, afterTextIndex := m.MecabWrapper.GetMatchIndex(features, unExchangeConditions)
-	sugar.Debugf("convertRequestMood() - after text exists: %v, index: %d", afterTextMatch, afterTextIndex)
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(requestMoodAfterTextMatcher, features)
+	if ce := m.Logger.Check(zap.DebugLevel, "convertRequestMood() - after text"); ce != nil {
+		ce.Write(zap.Bool("exists", afterTextMatch), zap.Int("index", afterTextIndex))
+	}
 
 	// 記号{0..*}+EOS -> なのだ+記号{0..*}+EOS
 	texts := []string{}
-	texts = append(texts, m.MecabWrapper.Construct(features[:exchangeIndex+3])) // 「ください」まで含める
+	texts = append(texts, m.MecabWrapper.Construct(features[:index+3])) // 「ください」まで含める
 	texts = append(texts, "なのだ")
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
 
-	sugar.Debugf("convertRequestMood() - exchange text: %v", texts)
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	if ce := m.Logger.Check(zap.DebugLevel, "convertRequestMood() - exchange"); ce != nil {
+		ce.Write(zap.Strings("text", texts))
+	}
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertRequestMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertRequestMood", err)}
 	}
 
-	sugar.Infof("convertRequestMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertRequestMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 同意のムード(変換無し)
-* 条件: ね + 記号{0..*} + EOS
-* ex) 一緒に腹筋したいね
+* 同意のムード(変換無し) 条件
  */
-func (m *MoodFilter) convertAgreementMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertAgreementMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getAgreementMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -939,27 +1013,26 @@ func (m *MoodFilter) convertAgreementMood(features []zunda_mecab.MecabFeature) M
 			},
 		},
 	}
+}
 
-	match, _ := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertAgreementMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
+/*
+* 同意のムード(変換無し)
+* 条件: ね + 記号{0..*} + EOS
+* ex) 一緒に腹筋したいね
+ */
+func (m *MoodFilter) convertAgreementMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertAgreementMood()")
+	if ce := m.Logger.Check(zap.InfoLevel, "convertAgreementMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(features)))
 	}
-	sugar.Infof("convertAgreementMood() - converted: %s", m.MecabWrapper.Construct(features))
 	return MoodConvertResult{Features: features, Parsed: true}
 }
 
 /*
-* 非断定のムード
-* 条件: と + 思う + 記号{0..*} + EOS
-* ex) 僕は腹筋できると思う
+* 非断定のムード 条件
  */
-func (m *MoodFilter) convertUndecisionMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertUndecisionMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getUndecisionMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1025,16 +1098,18 @@ func (m *MoodFilter) convertUndecisionMood(features []zunda_mecab.MecabFeature)
 			},
 		},
 	}
+}
 
-	match, _ := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertUndecisionMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 非断定のムード
+* 条件: と + 思う + 記号{0..*} + EOS
+* ex) 僕は腹筋できると思う
+ */
+func (m *MoodFilter) convertUndecisionMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertUndecisionMood()")
 
 	// ムード後の文字列
-	afterTextConditions := conditions[2:]
-	afterTextMatch, afterTextIndex := m.MecabWrapper.GetMatchIndex(features, afterTextConditions)
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(undecisionMoodAfterTextMatcher, features)
 
 	// 記号{0..*}+EOS -> なのだ+記号{0..*}+EOS
 	texts := []string{}
@@ -1043,27 +1118,22 @@ func (m *MoodFilter) convertUndecisionMood(features []zunda_mecab.MecabFeature)
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertUndecisionMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertUndecisionMood", err)}
 	}
 
-	sugar.Infof("convertUndecisionMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertUndecisionMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 質問のムード
-* 条件: か + 記号{0..*} + EOS
-* ex) 一緒腹筋したか
+* 質問のムード 条件
  */
-func (m *MoodFilter) convertQuestionMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertQuestionMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getQuestionMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1113,45 +1183,42 @@ func (m *MoodFilter) convertQuestionMood(features []zunda_mecab.MecabFeature) Mo
 			},
 		},
 	}
+}
 
-	match, conditionIndex := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertQuestionMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 質問のムード
+* 条件: か + 記号{0..*} + EOS
+* ex) 一緒腹筋したか
+ */
+func (m *MoodFilter) convertQuestionMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertQuestionMood()")
 
 	// ムード後の文字列
-	afterTextConditions := conditions[1:]
-	afterTextMatch, afterTextIndex := m.MecabWrapper.GetMatchIndex(features, afterTextConditions)
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(questionMoodAfterTextMatcher, features)
 
 	// 記号{0..*}+EOS -> なのだ+記号{0..*}+EOS
 	texts := []string{}
-	texts = append(texts, m.MecabWrapper.Construct(features[:conditionIndex]))
+	texts = append(texts, m.MecabWrapper.Construct(features[:index]))
 	texts = append(texts, "のだ")
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertQuestionMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertQuestionMood", err)}
 	}
 
-	sugar.Infof("convertQuestionMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertQuestionMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 質問(意志)のムード
-* 条件: のか + 記号{0..*} + EOS
-* ex) 一緒腹筋したのか
+* 質問(意志)のムード 条件
  */
-func (m *MoodFilter) convertQuestionIntentionMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertQuestionIntentionMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getQuestionIntentionMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1217,44 +1284,42 @@ func (m *MoodFilter) convertQuestionIntentionMood(features []zunda_mecab.MecabFe
 			},
 		},
 	}
+}
 
-	match, conditionIndex := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertQuestionIntentionMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 質問(意志)のムード
+* 条件: のか + 記号{0..*} + EOS
+* ex) 一緒腹筋したのか
+ */
+func (m *MoodFilter) convertQuestionIntentionMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertQuestionIntentionMood()")
 
 	// ムード後の文字列
-	afterTextMatch := (conditionIndex + 2) <= len(features)
+	afterTextMatch := (index + 2) <= len(features)
 
 	// のか + 記号{0..*} + EOS -> のだ + 記号{0..*} + EOS
 	texts := []string{}
-	texts = append(texts, m.MecabWrapper.Construct(features[:conditionIndex]))
+	texts = append(texts, m.MecabWrapper.Construct(features[:index]))
 	texts = append(texts, "のだ")
 	if afterTextMatch {
-		texts = append(texts, m.MecabWrapper.Construct(features[conditionIndex+2:]))
+		texts = append(texts, m.MecabWrapper.Construct(features[index+2:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertQuestionIntentionMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertQuestionIntentionMood", err)}
 	}
 
-	sugar.Infof("convertQuestionIntentionMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertQuestionIntentionMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 命令のムード
-* 条件: 動詞 + なさい + 記号{0..*} + EOS
-* ex) 一緒に腹筋しなさい
+* 命令のムード 条件
  */
-func (m *MoodFilter) convertOrderMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertOrderMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getOrderMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1320,45 +1385,42 @@ func (m *MoodFilter) convertOrderMood(features []zunda_mecab.MecabFeature) MoodC
 			},
 		},
 	}
+}
 
-	match, conditionIndex := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertOrderMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 命令のムード
+* 条件: 動詞 + なさい + 記号{0..*} + EOS
+* ex) 一緒に腹筋しなさい
+ */
+func (m *MoodFilter) convertOrderMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertOrderMood()")
 
 	// ムード後の文字列
-	afterTextConditions := conditions[2:]
-	afterTextMatch, afterTextIndex := m.MecabWrapper.GetMatchIndex(features, afterTextConditions)
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(orderMoodAfterTextMatcher, features)
 
 	// 記号{0..*}+EOS -> なのだ+記号{0..*}+EOS
 	texts := []string{}
-	texts = append(texts, m.MecabWrapper.Construct(features[:conditionIndex+2]))
+	texts = append(texts, m.MecabWrapper.Construct(features[:index+2]))
 	texts = append(texts, "なのだ")
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertOrderMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertOrderMood", err)}
 	}
 
-	sugar.Infof("convertOrderMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertOrderMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 命令のムード(体言止め)
-* 条件: 動詞(命令) + 記号{0..*} + EOS
-* ex) 一緒に闘え
+* 命令のムード(体言止め) 条件
  */
-func (m *MoodFilter) convertOrderTaigenMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertOrderTaigenMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getOrderTaigenMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1408,50 +1470,48 @@ func (m *MoodFilter) convertOrderTaigenMood(features []zunda_mecab.MecabFeature)
 			},
 		},
 	}
+}
+
+/*
+* 命令のムード(体言止め)
+* 条件: 動詞(命令) + 記号{0..*} + EOS
+* ex) 一緒に闘え
+ */
+func (m *MoodFilter) convertOrderTaigenMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertOrderTaigenMood()")
 
-	match, conditionIndex := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertOrderTaigenMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
 	// 動詞の活用が命令形
-	if !features[conditionIndex].ConjugationForm.IsMeirei() {
-		sugar.Debug("convertOrderTaigenMood() - not meirei conjugation form")
+	if !features[index].ConjugationForm.IsMeirei() {
+		m.Logger.Debug("convertOrderTaigenMood() - not meirei conjugation form")
 		return MoodConvertResult{Features: features, Parsed: false}
 	}
 
 	// ムード後の文字列
-	afterTextConditions := conditions[1:]
-	afterTextMatch, afterTextIndex := m.MecabWrapper.GetMatchIndex(features, afterTextConditions)
+	afterTextMatch, afterTextIndex := m.MecabWrapper.GetCompiledMatchIndex(orderTaigenMoodAfterTextMatcher, features)
 
 	// 記号{0..*}+EOS -> なのだ+記号{0..*}+EOS
 	texts := []string{}
-	texts = append(texts, m.MecabWrapper.Construct(features[:conditionIndex+1]))
+	texts = append(texts, m.MecabWrapper.Construct(features[:index+1]))
 	texts = append(texts, "なのだ")
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[afterTextIndex:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertOrderTaigenMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertOrderTaigenMood", err)}
 	}
 
-	sugar.Infof("convertOrderTaigenMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertOrderTaigenMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 断定-口頭のムード
-* 条件: んだ
-* ex) 僕は腹筋できると思う
+* 断定-口頭のムード 条件
  */
-func (m *MoodFilter) convertConclusionConversationMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertConclusionConversationMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getConclusionConversationMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1485,12 +1545,15 @@ func (m *MoodFilter) convertConclusionConversationMood(features []zunda_mecab.Me
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertConclusionConversationMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 断定-口頭のムード
+* 条件: んだ
+* ex) 僕は腹筋できると思う
+ */
+func (m *MoodFilter) convertConclusionConversationMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertConclusionConversationMood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 1) < len(features)
@@ -1502,27 +1565,22 @@ func (m *MoodFilter) convertConclusionConversationMood(features []zunda_mecab.Me
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+2:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertConclusionConversationMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertConclusionConversationMood", err)}
 	}
 
-	sugar.Infof("convertConclusionConversationMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertConclusionConversationMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 許可のムード
-* 条件: してもよい + 記号{0..*} + EOS
-* ex) 一緒に腹筋してもよい
+* 許可のムード 条件
  */
-func (m *MoodFilter) convertAllowMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertAllowMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getAllowMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1620,12 +1678,15 @@ func (m *MoodFilter) convertAllowMood(features []zunda_mecab.MecabFeature) MoodC
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertAllowMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 許可のムード
+* 条件: してもよい + 記号{0..*} + EOS
+* ex) 一緒に腹筋してもよい
+ */
+func (m *MoodFilter) convertAllowMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertAllowMood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 4) < len(features)
@@ -1637,27 +1698,22 @@ func (m *MoodFilter) convertAllowMood(features []zunda_mecab.MecabFeature) MoodC
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+4:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertAllowMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertAllowMood", err)}
 	}
 
-	sugar.Infof("convertAllowMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertAllowMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 願望のムード
-* 条件: したい + 記号{0..*} + EOS
-* ex) 僕は腹筋したい
+* 願望のムード 条件
  */
-func (m *MoodFilter) convertDesireMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertDesireMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getDesireMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1723,12 +1779,15 @@ func (m *MoodFilter) convertDesireMood(features []zunda_mecab.MecabFeature) Mood
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertDesireMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 願望のムード
+* 条件: したい + 記号{0..*} + EOS
+* ex) 僕は腹筋したい
+ */
+func (m *MoodFilter) convertDesireMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertDesireMood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 2) < len(features)
@@ -1740,27 +1799,22 @@ func (m *MoodFilter) convertDesireMood(features []zunda_mecab.MecabFeature) Mood
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+2:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertDesireMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertDesireMood", err)}
 	}
 
-	sugar.Infof("convertDesireMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertDesireMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 禁止のムード
-* 条件: 動詞 + てはいけない + 記号{0..*} + EOS
-* ex) 一緒に腹筋してはいけない。
+* 禁止のムード 条件
  */
-func (m *MoodFilter) convertProhibitionMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertProhibitionMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getProhibitionMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1874,12 +1928,15 @@ func (m *MoodFilter) convertProhibitionMood(features []zunda_mecab.MecabFeature)
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertProhibitionMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 禁止のムード
+* 条件: 動詞 + てはいけない + 記号{0..*} + EOS
+* ex) 一緒に腹筋してはいけない。
+ */
+func (m *MoodFilter) convertProhibitionMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertProhibitionMood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 5) < len(features)
@@ -1891,27 +1948,22 @@ func (m *MoodFilter) convertProhibitionMood(features []zunda_mecab.MecabFeature)
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+5:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertProhibitionMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertProhibitionMood", err)}
 	}
 
-	sugar.Infof("convertProhibitionMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertProhibitionMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 意志のムード
-* 条件: (動詞|形容詞|助動詞) + の + 記号{0..*} + EOS
-* ex) ここで良いの, ここが大事なの？
+* 意志のムード 条件
  */
-func (m *MoodFilter) convertIntention2Mood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertIntention2Mood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getIntention2MoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -1999,12 +2051,15 @@ func (m *MoodFilter) convertIntention2Mood(features []zunda_mecab.MecabFeature)
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertIntention2Mood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 意志のムード
+* 条件: (動詞|形容詞|助動詞) + の + 記号{0..*} + EOS
+* ex) ここで良いの, ここが大事なの？
+ */
+func (m *MoodFilter) convertIntention2Mood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertIntention2Mood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 2) < len(features)
@@ -2016,27 +2071,22 @@ func (m *MoodFilter) convertIntention2Mood(features []zunda_mecab.MecabFeature)
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+2:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertIntention2Mood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertIntention2Mood", err)}
 	}
 
-	sugar.Infof("convertIntention2Mood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertIntention2Mood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 動詞分-過去-た
-* 条件: (動詞|形容詞|助動詞) + た + 記号{0..*} + EOS
-* ex) ここで良いの, ここが大事なの？
+* 動詞分-過去-た 条件
  */
-func (m *MoodFilter) convertPastMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertPastMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getPastMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -2124,12 +2174,15 @@ func (m *MoodFilter) convertPastMood(features []zunda_mecab.MecabFeature) MoodCo
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertPastMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 動詞分-過去-た
+* 条件: (動詞|形容詞|助動詞) + た + 記号{0..*} + EOS
+* ex) ここで良いの, ここが大事なの？
+ */
+func (m *MoodFilter) convertPastMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertPastMood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 2) < len(features)
@@ -2141,27 +2194,22 @@ func (m *MoodFilter) convertPastMood(features []zunda_mecab.MecabFeature) MoodCo
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+2:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertPastMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertPastMood", err)}
 	}
 
-	sugar.Infof("convertPastMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertPastMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* ナイ形容詞語幹
-* 条件: 名詞-ナイ形容詞語幹 + ない + 記号{0..*} + EOS
-* ex) それはしょうがない
+* ナイ形容詞語幹 条件
  */
-func (m *MoodFilter) convertNaiAdjectiveMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertNaiAdjectiveMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getNaiAdjectiveMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -2231,12 +2279,15 @@ func (m *MoodFilter) convertNaiAdjectiveMood(features []zunda_mecab.MecabFeature
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertNaiAdjectiveMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* ナイ形容詞語幹
+* 条件: 名詞-ナイ形容詞語幹 + ない + 記号{0..*} + EOS
+* ex) それはしょうがない
+ */
+func (m *MoodFilter) convertNaiAdjectiveMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertNaiAdjectiveMood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 2) < len(features)
@@ -2248,27 +2299,22 @@ func (m *MoodFilter) convertNaiAdjectiveMood(features []zunda_mecab.MecabFeature
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+2:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertNaiAdjectiveMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertNaiAdjectiveMood", err)}
 	}
 
-	sugar.Infof("convertNaiAdjectiveMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertNaiAdjectiveMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
 
 /*
-* 不安の「の」
-* 条件: の + 記号{0..*} + EOS
-* ex) それはいいの
+* 不安の「の」 条件
  */
-func (m *MoodFilter) convertAnxietyMood(features []zunda_mecab.MecabFeature) MoodConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertAnxietyMood()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getAnxietyMoodConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -2320,12 +2366,15 @@ func (m *MoodFilter) convertAnxietyMood(features []zunda_mecab.MecabFeature) Moo
 			},
 		},
 	}
+}
 
-	match, index := m.MecabWrapper.GetMatchIndex(features, conditions)
-	if !match {
-		sugar.Debug("convertAnxietyMood() - not match")
-		return MoodConvertResult{Features: features, Parsed: false}
-	}
+/*
+* 不安の「の」
+* 条件: の + 記号{0..*} + EOS
+* ex) それはいいの
+ */
+func (m *MoodFilter) convertAnxietyMood(ctx context.Context, features []zunda_mecab.MecabFeature, index int) MoodConvertResult {
+	m.Logger.Debug("convertAnxietyMood()")
 
 	// ムード後の文字列
 	afterTextMatch := (index + 1) < len(features)
@@ -2337,12 +2386,13 @@ func (m *MoodFilter) convertAnxietyMood(features []zunda_mecab.MecabFeature) Moo
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[index+1:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertAnxietyMood() - %v", err)
-		return MoodConvertResult{Features: features, Parsed: false}
+		return MoodConvertResult{Features: features, Parsed: false, Err: newConvertError(moodFilterName, "convertAnxietyMood", err)}
 	}
 
-	sugar.Infof("convertAnxietyMood() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertAnxietyMood()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return MoodConvertResult{Features: exchangeFeatures, Parsed: true}
 }
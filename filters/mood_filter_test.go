@@ -1,6 +1,9 @@
 package filters
 
 import (
+	"bufio"
+	"go.uber.org/zap"
+	"os"
 	"testing"
 	"zundafilter/zunda_mecab"
 )
@@ -288,3 +291,110 @@ func TestMoodFilter(t *testing.T) {
 		})
 	}
 }
+
+/*
+* data/source.txtの各行をパースしたFeature
+ */
+func getMoodBenchmarkFeatures(b *testing.B, mecabWrapper *zunda_mecab.MecabWrapper) [][]zunda_mecab.MecabFeature {
+	file, err := os.Open("../data/source.txt")
+	if err != nil {
+		b.Skipf("can not open source.txt: %v", err)
+	}
+	defer file.Close()
+
+	featuresList := [][]zunda_mecab.MecabFeature{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		features, err := mecabWrapper.ParseToNode(scanner.Text())
+		if err != nil {
+			b.Skipf("can not parse source.txt: %v", err)
+		}
+		featuresList = append(featuresList, features)
+	}
+	return featuresList
+}
+
+/*
+* ルール毎にGetMatchIndexで照合(コンパイル前の方式)
+ */
+func BenchmarkMoodMatchSequential(b *testing.B) {
+	mecabWrapper := zunda_mecab.MecabWrapper{
+		Logger: zap.NewNop(),
+	}
+	featuresList := getMoodBenchmarkFeatures(b, &mecabWrapper)
+	rules := getMoodRules()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, features := range featuresList {
+			for _, rule := range rules {
+				if match, _ := mecabWrapper.GetMatchIndex(features, rule.Conditions); match {
+					break
+				}
+			}
+		}
+	}
+}
+
+/*
+* コンパイル済みマッチャーで照合
+ */
+func BenchmarkMoodMatchCompiled(b *testing.B) {
+	mecabWrapper := zunda_mecab.MecabWrapper{
+		Logger: zap.NewNop(),
+	}
+	featuresList := getMoodBenchmarkFeatures(b, &mecabWrapper)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, features := range featuresList {
+			mecabWrapper.MatchAll(moodMatcher, features)
+		}
+	}
+}
+
+/*
+* コンパイル済みのムード後の文字列の条件はGetMatchIndexと同じ位置で合致する
+ */
+func TestMoodAfterTextMatchers(t *testing.T) {
+	tests := []struct {
+		name       string
+		matcher    *zunda_mecab.MecabMatcher
+		conditions []zunda_mecab.MecabCondition
+	}{
+		{name: "確認", matcher: confirmationMoodAfterTextMatcher, conditions: getConfirmationMoodConditions()[2:]},
+		{name: "断定", matcher: affirmativeMoodAfterTextMatcher, conditions: getAffirmativeMoodConditions()[2:]},
+		{name: "確信", matcher: confidenceMoodAfterTextMatcher, conditions: getConfidenceMoodConditions()[2:]},
+		{name: "勧誘", matcher: invitationMoodAfterTextMatcher, conditions: getInvitationMoodConditions()[3:]},
+		{name: "依頼", matcher: requestMoodAfterTextMatcher, conditions: getRequestMoodConditions()[3:]},
+		{name: "非断定", matcher: undecisionMoodAfterTextMatcher, conditions: getUndecisionMoodConditions()[2:]},
+		{name: "質問", matcher: questionMoodAfterTextMatcher, conditions: getQuestionMoodConditions()[1:]},
+		{name: "命令", matcher: orderMoodAfterTextMatcher, conditions: getOrderMoodConditions()[2:]},
+		{name: "命令(体言止め)", matcher: orderTaigenMoodAfterTextMatcher, conditions: getOrderTaigenMoodConditions()[1:]},
+	}
+	noun := zunda_mecab.MecabFeature{Word: "人", WordType: zunda_mecab.MecabWordTypeNoun}
+	symbol := zunda_mecab.MecabFeature{Word: "。", WordType: zunda_mecab.MecabWordTypeSymbol}
+	eos := zunda_mecab.MecabFeature{EOS: true}
+	featuresList := [][]zunda_mecab.MecabFeature{
+		{},
+		{eos},
+		{noun, eos},
+		{noun, symbol, eos},
+		{noun, symbol, symbol, eos},
+		{noun, symbol, noun, eos},
+	}
+
+	mecabWrapper := zunda_mecab.MecabWrapper{
+		Logger: zap.NewNop(),
+	}
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			for _, features := range featuresList {
+				expectMatch, expectIndex := mecabWrapper.GetMatchIndex(features, testCase.conditions)
+				actualMatch, actualIndex := mecabWrapper.GetCompiledMatchIndex(testCase.matcher, features)
+				if actualMatch != expectMatch || (expectMatch && actualIndex != expectIndex) {
+					t.Fatalf("GetCompiledMatchIndex(%v) = (%v, %v), expect (%v, %v)", features, actualMatch, actualIndex, expectMatch, expectIndex)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package filters
+
+/*
+* 変換後の話者
+* 指定できるのは一人称(FirstPerson)のみ
+* 語尾(〜のだ)はムードの変換規則で固定のため、語尾等の指定は無い
+ */
+type Persona struct {
+	FirstPerson string // 一人称(代名詞の置換先)。空の場合はDefaultPersonaの一人称
+}
+
+var DefaultPersona = Persona{
+	FirstPerson: "ぼく",
+}
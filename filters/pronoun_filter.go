@@ -1,59 +1,120 @@
 package filters
 
 import (
+	"context"
 	"go.uber.org/zap"
 	"strings"
 	"zundafilter/zunda_mecab"
 )
 
+const pronounFilterName = "PronounFilter"
+
 type PronounFilter struct {
-	MecabWrapper *zunda_mecab.MecabWrapper
-	Logger       *zap.Logger
+	MecabWrapper  *zunda_mecab.MecabWrapper
+	Logger        *zap.Logger
+	Persona       Persona  // FirstPersonが空の場合はDefaultPersona
+	DisabledRules []string // 適用しない規則名(pronounRulesのName)
+	Stats         *Stats
+}
+
+/*
+* 代名詞の変換規則
+* 並び順が優先度(先頭から順に試し、最初に変換できたルールを採用する)
+ */
+type pronounRule struct {
+	Name    string
+	Convert func(*PronounFilter, context.Context, []zunda_mecab.MecabFeature) PronounConvertResult
+}
+
+var pronounRules = []pronounRule{
+	{Name: "pronoun", Convert: (*PronounFilter).convertPronoun},
+}
+
+// 代名詞の変換の条件(起動時に一度だけコンパイルする)
+var pronounMatcher = zunda_mecab.CompileMecabMatcher(getPronounConditions())
+
+var pronounFilterDefinition = FilterDefinition{
+	Name:  FilterPronoun,
+	Rules: pronounRuleNames(),
+	New: func(z *ZundaFilter, disabledRules []string) Converter {
+		return &PronounFilter{
+			MecabWrapper:  z.MecabWrapper,
+			Logger:        z.Logger,
+			Persona:       z.Persona,
+			DisabledRules: disabledRules,
+			Stats:         z.Stats,
+		}
+	},
 }
 type PronounConvertResult struct {
 	Features []zunda_mecab.MecabFeature
 	Parsed   bool
+	Err      error // 変換規則の実行時エラー。ParsedはfalseでFeaturesは変換前のまま
+}
+
+func pronounRuleNames() []string {
+	names := []string{}
+	for _, rule := range pronounRules {
+		names = append(names, rule.Name)
+	}
+	return names
 }
 
 func (m *PronounFilter) Convert(text string) (string, error) {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
+	return m.ConvertContext(context.Background(), text)
+}
 
-	features, err := m.MecabWrapper.ParseToNode(text)
+func (m *PronounFilter) ConvertContext(ctx context.Context, text string) (string, error) {
+	result, err := m.ConvertEdits(ctx, text)
+	return result.Text, err
+}
+
+func (m *PronounFilter) ConvertEdits(ctx context.Context, text string) (ConvertResult, error) {
+
+	features, err := m.MecabWrapper.ParseToNodeContext(ctx, text)
 	if err != nil {
-		return "", nil
+		return ConvertResult{}, newConvertError(pronounFilterName, "Convert", err)
 	}
 
 	// パース結果の出力
 	for _, feature := range features {
-		sugar.Debug(feature.String())
+		if ce := m.Logger.Check(zap.DebugLevel, "feature"); ce != nil {
+			ce.Write(zap.String("feature", feature.String()))
+		}
 	}
 
-	converters := []func([]zunda_mecab.MecabFeature) PronounConvertResult{
-		m.convertPronoun,
-	}
-	for _, converter := range converters {
-		PronounConvertResult := converter(features)
+	for _, rule := range pronounRules {
+		if containsName(m.DisabledRules, rule.Name) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return ConvertResult{}, err
+		}
+		PronounConvertResult := rule.Convert(m, ctx, features)
+		if PronounConvertResult.Err != nil {
+			if err := skipRuleFailure(m.Logger, PronounConvertResult.Err); err != nil {
+				return ConvertResult{}, err
+			}
+			continue
+		}
 		if PronounConvertResult.Parsed {
-			return m.MecabWrapper.Construct(PronounConvertResult.Features), nil
+			m.Stats.addRuleHit(FilterPronoun, rule.Name)
+			editor := newFeatureEditor(FilterPronoun, text, features)
+			editor.apply(rule.Name, PronounConvertResult.Features)
+			convertedText, edits := editor.result()
+			return ConvertResult{Original: text, Text: convertedText, Edits: edits}, nil
 		}
 	}
 
-	return text, nil
+	return ConvertResult{Original: text, Text: text, Edits: []Edit{}}, nil
 
 }
 
 /*
-* 代名詞の変換
-* 条件: 代名詞 + 助詞
-* ex) 私は野球が好きです
+* 代名詞の変換 条件
  */
-func (m *PronounFilter) convertPronoun(features []zunda_mecab.MecabFeature) PronounConvertResult {
-	defer m.Logger.Sync()
-	sugar := m.Logger.Sugar()
-	sugar.Debug("convertPronoun()")
-
-	conditions := []zunda_mecab.MecabCondition{
+func getPronounConditions() []zunda_mecab.MecabCondition {
+	return []zunda_mecab.MecabCondition{
 		{
 			ConditionType: zunda_mecab.MecabConditionTypeOne,
 			Features: []zunda_mecab.MecabConditionFeature{
@@ -91,29 +152,43 @@ func (m *PronounFilter) convertPronoun(features []zunda_mecab.MecabFeature) Pron
 			},
 		},
 	}
+}
 
-	match, conditionIndex := m.MecabWrapper.GetMatchIndex(features, conditions)
+/*
+* 代名詞の変換
+* 条件: 代名詞 + 助詞
+* ex) 私は野球が好きです
+ */
+func (m *PronounFilter) convertPronoun(ctx context.Context, features []zunda_mecab.MecabFeature) PronounConvertResult {
+	m.Logger.Debug("convertPronoun()")
+
+	match, conditionIndex := m.MecabWrapper.GetCompiledMatchIndex(pronounMatcher, features)
 	if !match {
-		sugar.Debug("convertPronoun() - not match")
+		m.Logger.Debug("convertPronoun() - not match")
 		return PronounConvertResult{Features: features, Parsed: false}
 	}
 
 	// ムード後の文字列
 	afterTextMatch := (conditionIndex + 1) < len(features)
 
-	// 代名詞 + 助詞 -> ぼく + 助詞
+	// 代名詞 + 助詞 -> 一人称(ぼく) + 助詞
+	firstPerson := m.Persona.FirstPerson
+	if firstPerson == "" {
+		firstPerson = DefaultPersona.FirstPerson
+	}
 	texts := []string{}
 	texts = append(texts, m.MecabWrapper.Construct(features[:conditionIndex]))
-	texts = append(texts, "ぼく")
+	texts = append(texts, firstPerson)
 	if afterTextMatch {
 		texts = append(texts, m.MecabWrapper.Construct(features[conditionIndex + 1:]))
 	}
-	exchangeFeatures, err := m.MecabWrapper.ParseToNode(strings.Join(texts, ""))
+	exchangeFeatures, err := m.MecabWrapper.ParseToNodeContext(ctx, strings.Join(texts, ""))
 	if err != nil {
-		sugar.Errorf("convertPronoun() - %v", err)
-		return PronounConvertResult{Features: features, Parsed: false}
+		return PronounConvertResult{Features: features, Parsed: false, Err: newConvertError(pronounFilterName, "convertPronoun", err)}
 	}
 
-	sugar.Infof("convertPronoun() - converted: %s", m.MecabWrapper.Construct(exchangeFeatures))
+	if ce := m.Logger.Check(zap.InfoLevel, "convertPronoun()"); ce != nil {
+		ce.Write(zap.String("converted", m.MecabWrapper.Construct(exchangeFeatures)))
+	}
 	return PronounConvertResult{Features: exchangeFeatures, Parsed: true}
 }
@@ -0,0 +1,79 @@
+package filters
+
+import (
+	"fmt"
+	"sync"
+)
+
+// 組み込みのフィルタ名
+const (
+	FilterHonorific = "honorific"
+	FilterMood      = "mood"
+	FilterPronoun   = "pronoun"
+)
+
+/*
+* フィルタの登録情報
+ */
+type FilterDefinition struct {
+	Name  string
+	Rules []string                                               // 無効にできる規則名(適用順)
+	New   func(z *ZundaFilter, disabledRules []string) Converter // disabledRulesはRulesのうち適用しない規則名
+}
+
+var (
+	filterRegistryMutex sync.RWMutex
+	filterRegistry      = map[string]FilterDefinition{}
+	filterNames         = []string{}
+)
+
+func init() {
+	// 登録順が既定の適用順
+	RegisterFilter(honorificFilterDefinition)
+	RegisterFilter(moodFilterDefinition)
+	RegisterFilter(pronounFilterDefinition)
+}
+
+/*
+* フィルタの登録
+* 同じ名前のフィルタが登録済みの場合はpanicする
+ */
+func RegisterFilter(definition FilterDefinition) {
+	filterRegistryMutex.Lock()
+	defer filterRegistryMutex.Unlock()
+	if _, ok := filterRegistry[definition.Name]; ok {
+		panic(fmt.Sprintf("filter %s is already registered", definition.Name))
+	}
+	filterRegistry[definition.Name] = definition
+	filterNames = append(filterNames, definition.Name)
+}
+
+func LookupFilter(name string) (FilterDefinition, bool) {
+	filterRegistryMutex.RLock()
+	defer filterRegistryMutex.RUnlock()
+	definition, ok := filterRegistry[name]
+	return definition, ok
+}
+
+/*
+* 登録済みのフィルタ名(既定の適用順)
+ */
+func FilterNames() []string {
+	filterRegistryMutex.RLock()
+	defer filterRegistryMutex.RUnlock()
+	return append([]string{}, filterNames...)
+}
+
+/*
+* 登録済みの規則名(<フィルタ名>.<規則名>)
+ */
+func RuleNames() []string {
+	names := []string{}
+	for _, filterName := range FilterNames() {
+		definition, _ := LookupFilter(filterName)
+		for _, rule := range definition.Rules {
+			names = append(names, filterName+"."+rule)
+		}
+	}
+	return names
+}
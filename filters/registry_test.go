@@ -0,0 +1,97 @@
+package filters
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestZundaFilterConverters(t *testing.T) {
+	tests := []struct {
+		name          string
+		filters       []string
+		disabledRules []string
+		expect        []string
+		expectDisable []string // MoodFilterで無効になる規則
+		expectError   bool
+	}{
+		{
+			name:   "指定なし",
+			expect: []string{FilterHonorific, FilterMood, FilterPronoun},
+		},
+		{
+			name:    "指定順に適用",
+			filters: []string{FilterPronoun, FilterHonorific},
+			expect:  []string{FilterPronoun, FilterHonorific},
+		},
+		{
+			name:          "規則の無効化",
+			filters:       []string{FilterMood},
+			disabledRules: []string{"mood.past", "honorific.specials"},
+			expect:        []string{FilterMood},
+			expectDisable: []string{"past"},
+		},
+		{
+			name:        "存在しないフィルタ",
+			filters:     []string{"unknown"},
+			expectError: true,
+		},
+		{
+			name:        "重複したフィルタ",
+			filters:     []string{FilterMood, FilterMood},
+			expectError: true,
+		},
+		{
+			name:          "存在しない規則",
+			disabledRules: []string{"mood.unknown"},
+			expectError:   true,
+		},
+		{
+			name:          "フィルタ名の無い規則",
+			disabledRules: []string{"past"},
+			expectError:   true,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			filter := ZundaFilter{
+				Filters:       testCase.filters,
+				DisabledRules: testCase.disabledRules,
+				Persona:       DefaultPersona,
+			}
+			converters, err := filter.converters()
+			if (err != nil) != testCase.expectError {
+				t.Fatalf("ZundaFilter.converters() error = %v, expect error %v", err, testCase.expectError)
+			}
+			if testCase.expectError {
+				return
+			}
+			actual := []string{}
+			for _, converter := range converters {
+				switch c := converter.(type) {
+				case *HonorificFilter:
+					actual = append(actual, FilterHonorific)
+				case *MoodFilter:
+					actual = append(actual, FilterMood)
+					if !reflect.DeepEqual(c.DisabledRules, testCase.expectDisable) {
+						t.Fatalf("MoodFilter.DisabledRules = %v, expect %v", c.DisabledRules, testCase.expectDisable)
+					}
+				case *PronounFilter:
+					actual = append(actual, FilterPronoun)
+				}
+			}
+			if !reflect.DeepEqual(actual, testCase.expect) {
+				t.Fatalf("ZundaFilter.converters() = %v, expect %v", actual, testCase.expect)
+			}
+		})
+	}
+}
+
+func TestRuleNames(t *testing.T) {
+	for _, name := range []string{"honorific.specials", "mood.past", "pronoun.pronoun"} {
+		if !containsName(RuleNames(), name) {
+			t.Fatalf("RuleNames() = %v, expect to contain %v", RuleNames(), name)
+		}
+	}
+}
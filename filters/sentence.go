@@ -0,0 +1,91 @@
+package filters
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// 文末の記号
+const sentenceTerminators = "。．！？!?"
+
+// 文末記号の直後に続く閉じ括弧
+const sentenceClosers = "」』）)】"
+
+// 1文の最大バイト数。文末記号が無いまま超えた場合は途中で区切る(読み込みのメモリを一定に保つため)
+const maxSentenceBytes = 16 * 1024
+
+/*
+* 文単位の分割
+* 改行、文末記号(と直後の閉じ括弧、改行)までを1文とする。分割結果を連結すると元のテキストに戻る
+* ex) "書きました。読みます" -> ["書きました。", "読みます"]
+ */
+func SplitSentences(text string) []string {
+	sentences := []string{}
+	data := []byte(text)
+	for len(data) > 0 {
+		advance, sentence, _ := ScanSentences(data, true)
+		sentences = append(sentences, string(sentence))
+		data = data[advance:]
+	}
+	return sentences
+}
+
+/*
+* 文単位で読み込むbufio.Scanner
+ */
+func NewSentenceScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), maxSentenceBytes+utf8.UTFMax)
+	scanner.Split(ScanSentences)
+	return scanner
+}
+
+/*
+* 文単位の分割(bufio.SplitFunc)
+* 改行を読んだら直ちに1文を返す(パイプからの入力を1行ずつ変換できるように)
+* 文末記号の後は閉じ括弧以外の文字を読むまで続きを読み込む
+ */
+func ScanSentences(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	i := 0
+	for i < len(data) {
+		if !atEOF && !utf8.FullRune(data[i:]) {
+			break
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		i += size
+		if r == '\n' || i >= maxSentenceBytes {
+			return i, data[:i], nil
+		}
+		if !strings.ContainsRune(sentenceTerminators, r) {
+			continue
+		}
+		// 連続する文末記号(「！？」等)と閉じ括弧は同じ文に含める。改行を含めたらそこで区切る
+		for i < len(data) {
+			if !atEOF && !utf8.FullRune(data[i:]) {
+				break
+			}
+			r, size := utf8.DecodeRune(data[i:])
+			if !strings.ContainsRune(sentenceTerminators+sentenceClosers+"\n", r) || i >= maxSentenceBytes {
+				return i, data[:i], nil
+			}
+			i += size
+			if r == '\n' {
+				return i, data[:i], nil
+			}
+		}
+		if atEOF || i >= maxSentenceBytes {
+			return i, data[:i], nil
+		}
+		// 続きに閉じ括弧があるかもしれない
+		return 0, nil, nil
+	}
+	if atEOF {
+		return i, data[:i], nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,166 @@
+package filters
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		expect []string
+	}{
+		{
+			name:   "句点",
+			text:   "書きました。読みます",
+			expect: []string{"書きました。", "読みます"},
+		},
+		{
+			name:   "連続する記号と改行",
+			text:   "本当ですか！？\n\nはい。",
+			expect: []string{"本当ですか！？\n", "\n", "はい。"},
+		},
+		{
+			name:   "改行",
+			text:   "書きました\n読みます",
+			expect: []string{"書きました\n", "読みます"},
+		},
+		{
+			name:   "閉じ括弧",
+			text:   "「行きます。」と言った",
+			expect: []string{"「行きます。」", "と言った"},
+		},
+		{
+			name:   "空文字",
+			text:   "",
+			expect: []string{},
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := SplitSentences(testCase.text)
+			if !reflect.DeepEqual(actual, testCase.expect) {
+				t.Fatalf("SplitSentences() = %q, expect %q", actual, testCase.expect)
+			}
+			if strings.Join(actual, "") != testCase.text {
+				t.Fatalf("SplitSentences() joined = %q, expect %q", strings.Join(actual, ""), testCase.text)
+			}
+		})
+	}
+}
+
+/*
+* 少しずつ読み込んでもSplitSentencesと同じ位置で分割する
+ */
+func TestSentenceScanner(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{
+			name: "句点と閉じ括弧",
+			text: "「行きます。」と言った。本当ですか！？\n\nはい",
+		},
+		{
+			name: "文末記号の無い長い文",
+			text: strings.Repeat("あ", maxSentenceBytes) + "。",
+		},
+		{
+			name: "長い改行の連続",
+			text: "はい" + strings.Repeat("\n", maxSentenceBytes*2),
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			scanner := NewSentenceScanner(iotest.HalfReader(strings.NewReader(testCase.text)))
+			actual := []string{}
+			for scanner.Scan() {
+				if len(scanner.Bytes()) > maxSentenceBytes+utf8.UTFMax {
+					t.Fatalf("Scan() length = %d, expect <= %d", len(scanner.Bytes()), maxSentenceBytes+utf8.UTFMax)
+				}
+				if !utf8.Valid(scanner.Bytes()) {
+					t.Fatalf("Scan() = %q, expect valid UTF-8", scanner.Bytes())
+				}
+				actual = append(actual, scanner.Text())
+			}
+			if err := scanner.Err(); err != nil {
+				t.Fatal(err)
+			}
+			expect := SplitSentences(testCase.text)
+			if !reflect.DeepEqual(actual, expect) {
+				t.Fatalf("Scan() = %d sentences, expect %d", len(actual), len(expect))
+			}
+			if strings.Join(actual, "") != testCase.text {
+				t.Fatalf("Scan() joined length = %d, expect %d", len(strings.Join(actual, "")), len(testCase.text))
+			}
+		})
+	}
+}
+
+/*
+* パイプから1行ずつ読み込む場合、次の行を待たずに文を返す
+ */
+func TestSentenceScannerPipe(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		expect []string
+	}{
+		{
+			name:   "文末記号の無い行",
+			line:   "書きました\n",
+			expect: []string{"書きました\n"},
+		},
+		{
+			name:   "句点で終わる行",
+			line:   "書きました。\n",
+			expect: []string{"書きました。\n"},
+		},
+		{
+			name:   "閉じ括弧の続く行",
+			line:   "「行きます。」と言った！\n",
+			expect: []string{"「行きます。」", "と言った！\n"},
+		},
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+	sentences := make(chan string)
+	go func() {
+		scanner := NewSentenceScanner(pipeReader)
+		for scanner.Scan() {
+			sentences <- scanner.Text()
+		}
+		close(sentences)
+	}()
+
+	for _, testCase := range tests {
+		if _, err := io.WriteString(pipeWriter, testCase.line); err != nil {
+			t.Fatal(err)
+		}
+		for _, expect := range testCase.expect {
+			select {
+			case actual := <-sentences:
+				if actual != expect {
+					t.Fatalf("%s: Scan() = %q, expect %q", testCase.name, actual, expect)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s: Scan() waits for the next line, expect %q", testCase.name, expect)
+			}
+		}
+	}
+	pipeWriter.Close()
+	if actual, ok := <-sentences; ok {
+		t.Fatalf("Scan() = %q, expect no more sentences", actual)
+	}
+}
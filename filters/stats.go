@@ -0,0 +1,61 @@
+package filters
+
+import (
+	"sync"
+)
+
+/*
+* 変換の集計(文の数、規則ごとの適用回数)
+* 複数のgoroutineから同時に集計できる。nilの場合は集計しない
+ */
+type Stats struct {
+	mutex     sync.Mutex
+	sentences int
+	ruleHits  map[string]int
+}
+
+func (s *Stats) addSentence() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sentences++
+}
+
+/*
+* 規則の適用(<フィルタ名>.<規則名>)
+ */
+func (s *Stats) addRuleHit(filterName string, ruleName string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ruleHits == nil {
+		s.ruleHits = map[string]int{}
+	}
+	s.ruleHits[filterName+"."+ruleName]++
+}
+
+/*
+* 変換した文の数
+ */
+func (s *Stats) Sentences() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sentences
+}
+
+/*
+* 規則ごとの適用回数(<フィルタ名>.<規則名>)
+ */
+func (s *Stats) RuleHits() map[string]int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ruleHits := make(map[string]int, len(s.ruleHits))
+	for name, count := range s.ruleHits {
+		ruleHits[name] = count
+	}
+	return ruleHits
+}
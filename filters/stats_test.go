@@ -0,0 +1,35 @@
+package filters
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	stats := &Stats{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stats.addSentence()
+			stats.addRuleHit(FilterMood, "past")
+		}()
+	}
+	wg.Wait()
+	stats.addRuleHit(FilterPronoun, "pronoun")
+
+	if stats.Sentences() != 10 {
+		t.Fatalf("Stats.Sentences() = %d, expect 10", stats.Sentences())
+	}
+	expect := map[string]int{"mood.past": 10, "pronoun.pronoun": 1}
+	if !reflect.DeepEqual(stats.RuleHits(), expect) {
+		t.Fatalf("Stats.RuleHits() = %v, expect %v", stats.RuleHits(), expect)
+	}
+
+	// nilの場合は集計しない
+	var nilStats *Stats
+	nilStats.addSentence()
+	nilStats.addRuleHit(FilterMood, "past")
+}
@@ -5,5 +5,7 @@ import (
 )
 
 type ZundaDbController interface {
-	SelectConvertVerbConjugationTable(baseWord string) (zunda_mecab.ConvertVerbConjugationRow, error)
+	SelectConjugation(baseWord string, conjugationType zunda_mecab.MecabConjugationType, conjugationForm zunda_mecab.MecabConjugationForm) (zunda_mecab.ConjugationRow, error)
+	// 文ごとに呼び出すため、読み込み済みの置換辞書を返すこと
+	SelectReplacements() ([]zunda_mecab.ReplacementRow, error)
 }
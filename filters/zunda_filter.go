@@ -1,48 +1,266 @@
 package filters
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
+	"io"
+	"strings"
 	"zundafilter/zunda_mecab"
 )
 
 type Converter interface {
 	Convert(text string) (string, error)
+	ConvertContext(ctx context.Context, text string) (string, error)
+	ConvertEdits(ctx context.Context, text string) (ConvertResult, error) // 変換結果と規則ごとの置換範囲
 }
 
+/*
+* 変換エラー時の扱い
+ */
+type ErrorPolicy int
+
+const (
+	ErrorPolicyStrict  ErrorPolicy = iota // 最初のエラーで変換を中止する
+	ErrorPolicyLenient                    // エラーとなった文は変換せずにそのまま出力し、警告とする
+)
+
 type ZundaFilter struct {
-	ZundaDb      ZundaDbController
-	MecabWrapper *zunda_mecab.MecabWrapper
-	Logger       *zap.Logger
+	ZundaDb       ZundaDbController
+	MecabWrapper  *zunda_mecab.MecabWrapper
+	Logger        *zap.Logger
+	ErrorPolicy   ErrorPolicy
+	Filters       []string // 適用するフィルタ名(指定順に適用する)。空の場合はFilterNames()
+	DisabledRules []string // 適用しない規則名(<フィルタ名>.<規則名> ex: mood.past)
+	Persona       Persona
+	Stats         *Stats // 文の数、規則の適用回数の集計先。nilの場合は集計しない
+}
+
+/*
+* キャンセル、タイムアウトによる変換の中断
+* 中断時に返すテキストは、変換済みの文と未変換の残りの文を連結したもの
+* errors.Is(err, context.Canceled)、errors.Is(err, context.DeadlineExceeded)で判定する
+ */
+type PartialError struct {
+	Converted int // 変換済みの文の数
+	Total     int // 文の数
+	Err       error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("converted %d of %d sentences: %v", e.Converted, e.Total, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
 }
 
 func (z *ZundaFilter) Convert(text string) (string, error) {
-	defer z.Logger.Sync()
-	sugar := z.Logger.Sugar()
-	sugar.Debug("ZundaFilter#Convert()")
-
-	converters := []Converter{
-		&HonorificFilter{
-			ZundaDb:      z.ZundaDb,
-			MecabWrapper: z.MecabWrapper,
-			Logger:       z.Logger,
-		},
-		&MoodFilter{
-			MecabWrapper: z.MecabWrapper,
-			Logger:       z.Logger,
-		},
-		&PronounFilter{
-			MecabWrapper: z.MecabWrapper,
-			Logger:       z.Logger,
-		},
-	}
-	var convertedText = text
+	return z.ConvertContext(context.Background(), text)
+}
+
+func (z *ZundaFilter) ConvertContext(ctx context.Context, text string) (string, error) {
+	convertedText, _, err := z.ConvertWithWarnings(ctx, text)
+	return convertedText, err
+}
+
+/*
+* 文単位の変換
+* ErrorPolicyLenientの場合、エラーとなった文はそのまま出力し、エラーを警告として返す
+* 文の間、変換規則の間でキャンセルを確認し、中断した場合はPartialErrorを返す
+ */
+func (z *ZundaFilter) ConvertWithWarnings(ctx context.Context, text string) (string, []error, error) {
+	result, warnings, err := z.ConvertEditsWithWarnings(ctx, text)
+	return result.Text, warnings, err
+}
+
+func (z *ZundaFilter) ConvertEdits(ctx context.Context, text string) (ConvertResult, error) {
+	result, _, err := z.ConvertEditsWithWarnings(ctx, text)
+	return result, err
+}
+
+/*
+* 置換範囲付きの変換
+* 置換範囲は文ごとの置換をテキスト全体の位置へずらしたもの。エラー、中断時の扱いはConvertWithWarningsと同じ
+ */
+func (z *ZundaFilter) ConvertEditsWithWarnings(ctx context.Context, text string) (ConvertResult, []error, error) {
+	builder := convertResultBuilder{}
+	warnings := []error{}
+	err := z.ConvertEach(ctx, strings.NewReader(text), func(result ConvertResult) error {
+		builder.add(result)
+		return nil
+	}, func(warning error) {
+		warnings = append(warnings, warning)
+	})
+	var partialError *PartialError
+	if err != nil && !errors.As(err, &partialError) {
+		return ConvertResult{Original: text, Text: "", Edits: []Edit{}}, warnings, err
+	}
+	return builder.result(), warnings, err
+}
+
+/*
+* rから1文ずつ読み込んで変換し、wへ書き込む
+* 入力全体を保持しないため、大きな入力でもメモリ使用量は一定となる
+* ErrorPolicyLenientの場合、エラーとなった文はそのまま書き込み、エラーをonWarningへ渡す
+* ErrorPolicyStrictの場合、エラーとなった文の手前まで書き込んだ状態でエラーを返す
+* 中断した場合は残りを変換せずに書き込み、PartialErrorを返す
+ */
+func (z *ZundaFilter) ConvertStream(ctx context.Context, r io.Reader, w io.Writer, onWarning func(error)) error {
+	return z.ConvertEach(ctx, r, func(result ConvertResult) error {
+		_, err := io.WriteString(w, result.Text)
+		return err
+	}, onWarning)
+}
+
+/*
+* rから1文ずつ読み込んで変換し、文ごとの変換結果をonResultへ渡す
+* エラー、中断時の扱いはConvertStreamと同じ(変換しない文は変換前と同じ変換結果とする)
+ */
+func (z *ZundaFilter) ConvertEach(ctx context.Context, r io.Reader, onResult func(ConvertResult) error, onWarning func(error)) error {
+	z.Logger.Debug("ZundaFilter#Convert()")
+
+	converters, err := z.converters()
+	if err != nil {
+		return err
+	}
+	scanner := NewSentenceScanner(r)
+	converted := 0
+	for scanner.Scan() {
+		sentence := scanner.Text()
+		result, err := z.convertSentence(ctx, converters, sentence)
+		if isContextError(err) {
+			// 変換中の文と残りの文は変換しない
+			return z.passRest(scanner, onResult, sentence, converted, err)
+		}
+		if err != nil {
+			if z.ErrorPolicy != ErrorPolicyLenient {
+				return err
+			}
+			if ce := z.Logger.Check(zap.WarnLevel, "ZundaFilter#Convert() - skip sentence"); ce != nil {
+				ce.Write(zap.String("sentence", sentence), zap.Error(err))
+			}
+			onWarning(err)
+			result = unconvertedResult(sentence)
+		}
+		if err := onResult(result); err != nil {
+			return err
+		}
+		z.Stats.addSentence()
+		converted++
+	}
+	return scanner.Err()
+}
+
+func (z *ZundaFilter) passRest(scanner *bufio.Scanner, onResult func(ConvertResult) error, sentence string, converted int, cause error) error {
+	total := converted + 1
+	if err := onResult(unconvertedResult(sentence)); err != nil {
+		return err
+	}
+	for scanner.Scan() {
+		if err := onResult(unconvertedResult(scanner.Text())); err != nil {
+			return err
+		}
+		total++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return &PartialError{
+		Converted: converted,
+		Total:     total,
+		Err:       cause,
+	}
+}
+
+func unconvertedResult(sentence string) ConvertResult {
+	return ConvertResult{Original: sentence, Text: sentence, Edits: []Edit{}}
+}
+
+/*
+* 設定の検証(フィルタ名の誤り等)
+ */
+func (z *ZundaFilter) Validate() error {
+	_, err := z.converters()
+	return err
+}
+
+func (z *ZundaFilter) converters() ([]Converter, error) {
+	names := z.Filters
+	if len(names) == 0 {
+		names = FilterNames()
+	}
+	definitions := []FilterDefinition{}
+	for _, name := range names {
+		definition, ok := LookupFilter(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown filter: %s (available: %s)", name, strings.Join(FilterNames(), ", "))
+		}
+		for _, added := range definitions {
+			if added.Name == name {
+				return nil, fmt.Errorf("duplicate filter: %s", name)
+			}
+		}
+		definitions = append(definitions, definition)
+	}
+
+	// フィルタごとの無効な規則
+	disabledRules := map[string][]string{}
+	for _, ruleName := range z.DisabledRules {
+		filterName, rule, _ := strings.Cut(ruleName, ".")
+		definition, ok := LookupFilter(filterName)
+		if !ok || !containsName(definition.Rules, rule) {
+			return nil, fmt.Errorf("unknown rule: %s (available: %s)", ruleName, strings.Join(RuleNames(), ", "))
+		}
+		disabledRules[filterName] = append(disabledRules[filterName], rule)
+	}
+
+	converters := []Converter{}
+	for _, definition := range definitions {
+		converters = append(converters, definition.New(z, disabledRules[definition.Name]))
+	}
+	return converters, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* 文の変換
+* フィルタごとの置換範囲を変換前の文の位置へ合成する
+ */
+func (z *ZundaFilter) convertSentence(ctx context.Context, converters []Converter, sentence string) (ConvertResult, error) {
+	edited := newEditedText(sentence)
+	convertedText := sentence
 	for _, converter := range converters {
-		resultText, err := converter.Convert(convertedText)
+		if err := ctx.Err(); err != nil {
+			return ConvertResult{}, err
+		}
+		result, err := converter.ConvertEdits(ctx, convertedText)
 		if err != nil {
-			return "", err
+			return ConvertResult{}, err
+		}
+		// 後ろの置換から適用すると前の置換の位置は変わらない
+		for i := len(result.Edits) - 1; i >= 0; i-- {
+			edit := result.Edits[i]
+			edited.replace(edit.Original.Start, edit.Original.End, result.Text[edit.Replacement.Start:edit.Replacement.End], edit.Filter, edit.Rule)
+		}
+		if edited.String() != result.Text {
+			// 置換範囲が変換結果と合わない場合は文全体の置換とする
+			edited.replace(0, edited.length(), result.Text, "", "")
+		}
+		convertedText = result.Text
+		if ce := z.Logger.Check(zap.InfoLevel, "ZundaFilter#filtered()"); ce != nil {
+			ce.Write(zap.String("text", result.Text))
 		}
-		convertedText = resultText
-		sugar.Infof("ZundaFilter#filtered() - %s", resultText)
 	}
-	return convertedText, nil
+	return ConvertResult{Original: sentence, Text: edited.String(), Edits: edited.edits()}, nil
 }
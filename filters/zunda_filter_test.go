@@ -1,26 +1,15 @@
 package filters
 
 import (
+	"context"
+	"errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"testing"
+	"time"
 	"zundafilter/zunda_mecab"
 )
 
-type TestZundaFilterDbAccessor struct{}
-
-func (t TestZundaFilterDbAccessor) SelectConvertVerbConjugationTable(baseWord string) (zunda_mecab.ConvertVerbConjugationRow, error) {
-	switch baseWord {
-	case "渡す":
-		return zunda_mecab.ConvertVerbConjugationRow{
-			BaseWord: "渡す",
-			Mizen:    "渡さ",
-		}, nil
-	default:
-		return zunda_mecab.ConvertVerbConjugationRow{}, nil
-	}
-}
-
 func getZundaFilterTestLogger() *zap.Logger {
 	level := zap.NewAtomicLevel()
 	level.SetLevel(zapcore.InfoLevel)
@@ -64,7 +53,7 @@ func TestZundaFilter(t *testing.T) {
 		testCase := testCase
 		t.Run(testCase.name, func(t *testing.T) {
 			t.Parallel()
-			zundaDbAccessor := TestZundaFilterDbAccessor{}
+			zundaDbAccessor := zunda_mecab.ConjugatorRepository{}
 			mecabWrapper := zunda_mecab.MecabWrapper{
 				Logger: getZundaFilterTestLogger(),
 			}
@@ -80,3 +69,122 @@ func TestZundaFilter(t *testing.T) {
 		})
 	}
 }
+
+/*
+* 置換辞書を参照できないzunda.db
+ */
+type brokenZundaDb struct {
+	zunda_mecab.ConjugatorRepository
+}
+
+func (b brokenZundaDb) SelectReplacements() ([]zunda_mecab.ReplacementRow, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestZundaFilterErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		errorPolicy    ErrorPolicy
+		text           string
+		expect         string
+		expectWarnings int
+		expectError    error
+	}{
+		{
+			name:        "strict",
+			errorPolicy: ErrorPolicyStrict,
+			text:        "ここからです。",
+			expect:      "",
+			expectError: ErrDictionaryMissing,
+		},
+		{
+			name:           "lenient",
+			errorPolicy:    ErrorPolicyLenient,
+			text:           "ここからです。私は行きます。",
+			expect:         "ここからです。私は行きます。",
+			expectWarnings: 2,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			mecabWrapper := zunda_mecab.MecabWrapper{
+				Logger: getZundaFilterTestLogger(),
+			}
+			filter := ZundaFilter{
+				ZundaDb:      brokenZundaDb{},
+				MecabWrapper: &mecabWrapper,
+				Logger:       getTestLogger(),
+				ErrorPolicy:  testCase.errorPolicy,
+			}
+			actual, warnings, err := filter.ConvertWithWarnings(context.Background(), testCase.text)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("ZundaFilter.ConvertWithWarnings() error = %v, expect %v", err, testCase.expectError)
+			}
+			if actual != testCase.expect {
+				t.Fatalf("ZundaFilter.ConvertWithWarnings() = %v, expect %v", actual, testCase.expect)
+			}
+			if len(warnings) != testCase.expectWarnings {
+				t.Fatalf("ZundaFilter.ConvertWithWarnings() warnings = %v, expect %d", warnings, testCase.expectWarnings)
+			}
+		})
+	}
+}
+
+func TestZundaFilterConvertContextCanceled(t *testing.T) {
+	tests := []struct {
+		name        string
+		newContext  func() (context.Context, context.CancelFunc)
+		errorPolicy ErrorPolicy
+		expectError error
+	}{
+		{
+			name: "キャンセル",
+			newContext: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+			expectError: context.Canceled,
+		},
+		{
+			name: "タイムアウト(lenientでも警告にしない)",
+			newContext: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			},
+			errorPolicy: ErrorPolicyLenient,
+			expectError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx, cancel := testCase.newContext()
+			defer cancel()
+			filter := ZundaFilter{
+				ZundaDb:      zunda_mecab.ConjugatorRepository{},
+				MecabWrapper: &zunda_mecab.MecabWrapper{Logger: getZundaFilterTestLogger()},
+				Logger:       getTestLogger(),
+				ErrorPolicy:  testCase.errorPolicy,
+			}
+			text := "ここからです。私は行きます。"
+			actual, warnings, err := filter.ConvertWithWarnings(ctx, text)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("ZundaFilter.ConvertWithWarnings() error = %v, expect %v", err, testCase.expectError)
+			}
+			var partialError *PartialError
+			if !errors.As(err, &partialError) || partialError.Converted != 0 || partialError.Total != 2 {
+				t.Fatalf("ZundaFilter.ConvertWithWarnings() error = %#v, expect PartialError{Converted: 0, Total: 2}", err)
+			}
+			// 未変換の文もそのまま返す
+			if actual != text {
+				t.Fatalf("ZundaFilter.ConvertWithWarnings() = %v, expect %v", actual, text)
+			}
+			if len(warnings) != 0 {
+				t.Fatalf("ZundaFilter.ConvertWithWarnings() warnings = %v, expect none", warnings)
+			}
+		})
+	}
+}
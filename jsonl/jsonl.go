@@ -0,0 +1,381 @@
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"zundafilter/filters"
+)
+
+// 変換するフィールドの既定値(JSON Pointer)
+const DEFAULT_POINTER = "/text"
+
+/*
+* JSON Lines(1行1オブジェクト)の変換
+* Pointersで指定したフィールドのみを変換し、他のフィールドはそのまま出力する
+ */
+type Converter struct {
+	Convert  filters.ConvertFunc
+	Pointers []string // 変換するフィールド(JSON Pointer ex: /text, /lines/0)。空の場合はDEFAULT_POINTER
+	MetaKey  string   // 適用した規則、エラーを追加するフィールド名。空の場合は追加しない
+	Workers  int      // 同時に変換するレコード数。1未満の場合は1
+}
+
+/*
+* レコードの変換エラー
+* 変換できなかったフィールドは変換前のまま出力する
+* JSONのオブジェクトとして読めない行はPointerを空とし、行を入力のまま出力する
+ */
+type RecordError struct {
+	Line    int
+	Pointer string
+	Err     error
+}
+
+func (e *RecordError) Error() string {
+	if e.Pointer == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: %s: %v", e.Line, e.Pointer, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+/*
+* rから1行ずつ読み込んで変換し、入力と同じ順にwへ書き込む
+* レコードの変換エラー、JSONのオブジェクトとして読めない行はonErrorへ渡して次のレコードを変換する
+ */
+func (c *Converter) Run(ctx context.Context, r io.Reader, w io.Writer, onError func(*RecordError)) error {
+	pointers := c.Pointers
+	if len(pointers) == 0 {
+		pointers = []string{DEFAULT_POINTER}
+	}
+	parsedPointers := make([][]string, len(pointers))
+	for i, pointer := range pointers {
+		tokens, err := parsePointer(pointer)
+		if err != nil {
+			return err
+		}
+		parsedPointers[i] = tokens
+	}
+	workers := c.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	// 読み込み順に結果を待つため、レコードごとの結果のchannelを順に渡す
+	type record struct {
+		line   int
+		data   []byte
+		result chan recordResult
+	}
+	records := make(chan record, workers)
+	queue := make(chan record, workers)
+	readErr := make(chan error, 1)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		defer close(records)
+		defer close(queue)
+		reader := bufio.NewReader(r)
+		for line := 1; ; line++ {
+			data, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(data)) > 0 {
+				rec := record{line: line, data: data, result: make(chan recordResult, 1)}
+				select {
+				case queue <- rec:
+				case <-ctx.Done():
+					readErr <- ctx.Err()
+					return
+				}
+				records <- rec
+			}
+			if err == io.EOF {
+				readErr <- nil
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		go func() {
+			for rec := range records {
+				rec.result <- c.convertRecord(ctx, rec.line, rec.data, pointers, parsedPointers)
+			}
+		}()
+	}
+
+	for rec := range queue {
+		result := <-rec.result
+		if result.err != nil {
+			return result.err
+		}
+		for _, recordError := range result.recordErrors {
+			onError(recordError)
+		}
+		if _, err := w.Write(append(result.data, '\n')); err != nil {
+			return err
+		}
+	}
+	return <-readErr
+}
+
+type recordResult struct {
+	data         []byte
+	recordErrors []*RecordError
+	err          error // 中断した
+}
+
+func (c *Converter) convertRecord(ctx context.Context, line int, data []byte, pointers []string, parsedPointers [][]string) recordResult {
+	if err := ctx.Err(); err != nil {
+		return recordResult{err: err}
+	}
+	// 読めない行はメタデータを追加できないため、入力のまま出力する
+	raw := bytes.TrimRight(data, "\r\n")
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, bytes.TrimSpace(data)); err != nil {
+		return recordResult{data: raw, recordErrors: []*RecordError{{Line: line, Err: err}}}
+	}
+	value := json.RawMessage(compacted.Bytes())
+	if !isObject(value) {
+		return recordResult{data: raw, recordErrors: []*RecordError{{Line: line, Err: errors.New("not a JSON object")}}}
+	}
+
+	rules := []string{}
+	recordErrors := []*RecordError{}
+	for i, tokens := range parsedPointers {
+		replaced, err := replacePointer(value, tokens, func(text string) (string, error) {
+			result, err := c.Convert(ctx, text)
+			if err != nil {
+				return text, err
+			}
+			for _, edit := range result.Edits {
+				rule := edit.Filter + "." + edit.Rule
+				if !contains(rules, rule) {
+					rules = append(rules, rule)
+				}
+			}
+			return result.Text, nil
+		})
+		if isContextError(err) {
+			return recordResult{err: err}
+		}
+		if errors.Is(err, errPointerNotFound) {
+			// フィールドの無いレコードは変換しない
+			continue
+		}
+		if err != nil {
+			recordErrors = append(recordErrors, &RecordError{Line: line, Pointer: pointers[i], Err: err})
+			continue
+		}
+		value = replaced
+	}
+
+	if c.MetaKey != "" {
+		meta := struct {
+			Rules  []string `json:"rules"`
+			Errors []string `json:"errors,omitempty"`
+		}{Rules: rules}
+		for _, recordError := range recordErrors {
+			meta.Errors = append(meta.Errors, recordError.Error())
+		}
+		metaValue, err := json.Marshal(meta)
+		if err != nil {
+			return recordResult{err: err}
+		}
+		value, err = setMember(value, c.MetaKey, metaValue)
+		if err != nil {
+			return recordResult{err: err}
+		}
+	}
+	return recordResult{data: value, recordErrors: recordErrors}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var errPointerNotFound = errors.New("pointer not found")
+
+/*
+* JSON Pointer(RFC 6901)の分解
+* ex) "/a~1b/0" -> ["a/b", "0"]
+ */
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" || !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer: %q (must start with /)", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+/*
+* tokensが指す文字列をreplaceの結果に置き換える
+* オブジェクトのメンバーの順序、他の値は変えない
+ */
+func replacePointer(value json.RawMessage, tokens []string, replace func(string) (string, error)) (json.RawMessage, error) {
+	if len(tokens) == 0 {
+		var text string
+		if err := json.Unmarshal(value, &text); err != nil {
+			return nil, errors.New("not a string")
+		}
+		converted, err := replace(text)
+		if err != nil {
+			return nil, err
+		}
+		return marshalString(converted)
+	}
+
+	if isObject(value) {
+		members, err := decodeObject(value)
+		if err != nil {
+			return nil, err
+		}
+		for i := range members {
+			if members[i].Key != tokens[0] {
+				continue
+			}
+			replaced, err := replacePointer(members[i].Value, tokens[1:], replace)
+			if err != nil {
+				return nil, err
+			}
+			members[i].Value = replaced
+			return encodeObject(members), nil
+		}
+		return nil, errPointerNotFound
+	}
+
+	var elements []json.RawMessage
+	if err := json.Unmarshal(value, &elements); err != nil {
+		return nil, errPointerNotFound
+	}
+	index, err := strconv.Atoi(tokens[0])
+	if err != nil || index < 0 || index >= len(elements) {
+		return nil, errPointerNotFound
+	}
+	replaced, err := replacePointer(elements[index], tokens[1:], replace)
+	if err != nil {
+		return nil, err
+	}
+	elements[index] = replaced
+	return encodeArray(elements), nil
+}
+
+/*
+* メンバーの追加(同じ名前のメンバーがある場合は置き換える)
+ */
+func setMember(value json.RawMessage, key string, memberValue json.RawMessage) (json.RawMessage, error) {
+	members, err := decodeObject(value)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if members[i].Key == key {
+			members[i].Value = memberValue
+			return encodeObject(members), nil
+		}
+	}
+	return encodeObject(append(members, objectMember{Key: key, Value: memberValue})), nil
+}
+
+type objectMember struct {
+	Key   string
+	Value json.RawMessage
+}
+
+func isObject(value json.RawMessage) bool {
+	trimmed := bytes.TrimSpace(value)
+	return len(trimmed) > 0 && trimmed[0] == '{'
+}
+
+/*
+* オブジェクトのメンバーを出現順に読み込む(map[string]interface{}では順序が変わるため)
+ */
+func decodeObject(value json.RawMessage) ([]objectMember, error) {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	members := []objectMember{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid object key: %v", token)
+		}
+		var memberValue json.RawMessage
+		if err := decoder.Decode(&memberValue); err != nil {
+			return nil, err
+		}
+		members = append(members, objectMember{Key: key, Value: memberValue})
+	}
+	return members, nil
+}
+
+func encodeObject(members []objectMember) json.RawMessage {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, member := range members {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, _ := marshalString(member.Key)
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(member.Value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes()
+}
+
+func encodeArray(elements []json.RawMessage) json.RawMessage {
+	var buffer bytes.Buffer
+	buffer.WriteByte('[')
+	for i, element := range elements {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		buffer.Write(element)
+	}
+	buffer.WriteByte(']')
+	return buffer.Bytes()
+}
+
+/*
+* HTMLのエスケープをしない文字列のJSON
+ */
+func marshalString(text string) (json.RawMessage, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(text); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buffer.Bytes(), "\n"), nil
+}
@@ -0,0 +1,96 @@
+package jsonl
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"zundafilter/filters/filterstest"
+)
+
+func TestConverterRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		pointers     []string
+		metaKey      string
+		input        string
+		expect       string
+		expectErrors int
+		expectError  bool
+	}{
+		{
+			name:   "既定のフィールド",
+			input:  "{\"id\": 2, \"text\": \"ずんだです\", \"note\": \"<b>です</b>\"}\n{\"id\":1,\"text\":\"はい\"}\n",
+			expect: "{\"id\":2,\"text\":\"ずんだなのだ\",\"note\":\"<b>です</b>\"}\n{\"id\":1,\"text\":\"はい\"}\n",
+		},
+		{
+			name:     "複数のフィールドとエスケープ",
+			pointers: []string{"/title", "/lines/1", "/a~1b"},
+			input:    `{"title":"題です","lines":["一です","二です"],"a/b":"三です","n":1.50}`,
+			expect:   "{\"title\":\"題なのだ\",\"lines\":[\"一です\",\"二なのだ\"],\"a/b\":\"三なのだ\",\"n\":1.50}\n",
+		},
+		{
+			name:    "メタデータ",
+			metaKey: "zunda",
+			input:   "{\"text\":\"ずんだです\"}\n\n{\"text\":\"error\"}\n{\"id\":3}",
+			expect: "{\"text\":\"ずんだなのだ\",\"zunda\":{\"rules\":[\"mood.affirmative\"]}}\n" +
+				"{\"text\":\"error\",\"zunda\":{\"rules\":[],\"errors\":[\"line 3: /text: convert error\"]}}\n" +
+				"{\"id\":3,\"zunda\":{\"rules\":[]}}\n",
+			expectErrors: 1,
+		},
+		{
+			name:         "文字列以外",
+			input:        `{"text":1}`,
+			expect:       "{\"text\":1}\n",
+			expectErrors: 1,
+		},
+		{
+			name:         "JSONではない行",
+			input:        "{\"text\":\"です\"}\nです\n",
+			expect:       "{\"text\":\"なのだ\"}\nです\n",
+			expectErrors: 1,
+		},
+		{
+			name:    "途中の不正な行",
+			metaKey: "zunda",
+			input:   "{\"text\":\"一です\"}\n{\"text\": \"二です\"\r\n[\"三です\"]\n{\"text\":\"四です\"}\n",
+			expect: "{\"text\":\"一なのだ\",\"zunda\":{\"rules\":[\"mood.affirmative\"]}}\n" +
+				"{\"text\": \"二です\"\n" +
+				"[\"三です\"]\n" +
+				"{\"text\":\"四なのだ\",\"zunda\":{\"rules\":[\"mood.affirmative\"]}}\n",
+			expectErrors: 2,
+		},
+		{
+			name:        "不正なJSON Pointer",
+			pointers:    []string{"text"},
+			input:       `{"text":"です"}`,
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			converter := Converter{
+				Convert:  filterstest.ConvertText,
+				Pointers: testCase.pointers,
+				MetaKey:  testCase.metaKey,
+				Workers:  4,
+			}
+			var output bytes.Buffer
+			recordErrors := 0
+			err := converter.Run(context.Background(), strings.NewReader(testCase.input), &output, func(*RecordError) {
+				recordErrors++
+			})
+			if (err != nil) != testCase.expectError {
+				t.Fatalf("Converter.Run() error = %v, expect error %v", err, testCase.expectError)
+			}
+			if output.String() != testCase.expect {
+				t.Fatalf("Converter.Run() = %q, expect %q", output.String(), testCase.expect)
+			}
+			if recordErrors != testCase.expectErrors {
+				t.Fatalf("Converter.Run() record errors = %d, expect %d", recordErrors, testCase.expectErrors)
+			}
+		})
+	}
+}
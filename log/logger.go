@@ -1,29 +1,82 @@
 package log
 
 import (
-	"os"
+	_ "embed"
 	"fmt"
-	"path/filepath"
+	"os"
+	"sync"
+	"zundafilter/resource"
+
 	"go.uber.org/zap"
-	"io/ioutil"
 	"gopkg.in/yaml.v2"
 )
 
-func GetLogger() *zap.Logger {
-	path, err := os.Executable()
-	if err != nil {
-		panic(err)
+const (
+	CONFIG_NAME = "config.yaml"
+	CONFIG_ENV  = "ZUNDAFILTER_CONFIG"
+)
+
+// 設定ファイルが見つからない場合の設定
+//
+//go:embed config.yaml
+var defaultConfigYaml []byte
+
+var (
+	mutex  sync.Mutex
+	logger *zap.Logger
+)
+
+/*
+* ロガーの初期化
+* 設定ファイルはconfigPath、環境変数ZUNDAFILTER_CONFIG、XDG_CONFIG_HOME等、実行ファイルの配置ディレクトリの順に探し、
+* いずれも無い場合は組み込みの設定を使う
+ */
+func Init(configPath string) error {
+	configYaml := defaultConfigYaml
+	path, err := resource.Resolve(resource.KindConfig, CONFIG_NAME, configPath, CONFIG_ENV)
+	if err == nil {
+		if configYaml, err = os.ReadFile(path); err != nil {
+			return err
+		}
 	}
-	binDir := filepath.Dir(path)
-	configYaml, err := ioutil.ReadFile(fmt.Sprintf("%s/data/config.yaml", binDir))
+	newLogger, err := build(configYaml)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("%s: %w", path, err)
 	}
+	setLogger(newLogger)
+	return nil
+}
+
+func build(configYaml []byte) (*zap.Logger, error) {
 	var config zap.Config
 	if err := yaml.Unmarshal(configYaml, &config); err != nil {
-		panic(err)
+		return nil, err
 	}
-	logger, _ := config.Build()
+	return config.Build()
+}
 
+/*
+* 初期化済みのロガー
+* Initを呼んでいない場合は設定ファイルを探して初期化し、失敗した場合は組み込みの設定を使う
+ */
+func GetLogger() *zap.Logger {
+	mutex.Lock()
+	current := logger
+	mutex.Unlock()
+	if current != nil {
+		return current
+	}
+	if err := Init(""); err != nil {
+		defaultLogger, _ := build(defaultConfigYaml)
+		setLogger(defaultLogger)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
 	return logger
 }
+
+func setLogger(newLogger *zap.Logger) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	logger = newLogger
+}
@@ -0,0 +1,23 @@
+package log
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestInit(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_DIRS", t.TempDir())
+	t.Setenv(CONFIG_ENV, "")
+
+	if err := Init(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatal("Init() expect error for missing config")
+	}
+	// 設定ファイルが無ければ組み込みの設定を使う
+	if err := Init(""); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if GetLogger() == nil {
+		t.Fatal("GetLogger() = nil")
+	}
+}
@@ -0,0 +1,117 @@
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"zundafilter/filters"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extensionast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// 先頭のfront matter(YAMLの---、TOMLの+++で囲まれた範囲)
+var frontMatterPattern = regexp.MustCompile(`\A(?:---\r?\n(?:.*\r?\n)*?---|\+\+\+\r?\n(?:.*\r?\n)*?\+\+\+)[ \t]*(?:\r?\n|\z)`)
+
+/*
+* Markdownを読み込んで本文のテキストのみを変換し、wへ書き込む
+* 見出し、段落、リストの項目、表のセルをそれぞれ1つの文脈として変換する
+* コード、リンクのURL、画像、HTML、front matterは変換せず、変換したテキスト以外は入力のまま出力する
+ */
+func Convert(ctx context.Context, r io.Reader, w io.Writer, convert filters.ConvertFunc) error {
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	start := 0
+	if loc := frontMatterPattern.FindIndex(source); loc != nil {
+		start = loc[1]
+	}
+	body := source[start:]
+	document := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(body))
+
+	var output bytes.Buffer
+	output.Write(source[:start])
+	position := 0
+	err = ast.Walk(document, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node.(type) {
+		case *ast.Paragraph, *ast.TextBlock, *ast.Heading, *extensionast.TableCell:
+		default:
+			return ast.WalkContinue, nil
+		}
+		segments := textSegments(node)
+		if len(segments) == 0 {
+			return ast.WalkSkipChildren, nil
+		}
+		converted, err := convertBlock(ctx, body, segments, convert)
+		if err != nil {
+			return ast.WalkStop, &filters.LineError{Line: bytes.Count(source[:start+segments[0].Start], []byte("\n")) + 1, Err: err}
+		}
+		output.Write(body[position:segments[0].Start])
+		output.WriteString(converted)
+		position = segments[len(segments)-1].Stop
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return err
+	}
+	output.Write(body[position:])
+	_, err = output.WriteTo(w)
+	return err
+}
+
+/*
+* ブロック内の変換するテキストの範囲(入力の位置順)
+* コード、画像、HTMLの内側は含めない。リンクはテキストのみを含め、URL、タイトルは含めない
+ */
+func textSegments(block ast.Node) []text.Segment {
+	segments := []text.Segment{}
+	ast.Walk(block, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node := node.(type) {
+		case *ast.CodeSpan, *ast.AutoLink, *ast.Image, *ast.RawHTML:
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			if node.Segment.Len() > 0 {
+				segments = append(segments, node.Segment)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return segments
+}
+
+/*
+* テキストの範囲を連結して変換し、範囲の間(強調の記号、コード、リンクの記号とURL、改行等)を元の位置に戻す
+* 置換された範囲の内側にあった強調、リンクの閉じ記号は置換後の範囲の後ろ、その他は前へ移す
+ */
+func convertBlock(ctx context.Context, source []byte, segments []text.Segment, convert filters.ConvertFunc) (string, error) {
+	var plain strings.Builder
+	gaps := []filters.Gap{}
+	for i, segment := range segments {
+		if i > 0 && segments[i-1].Stop < segment.Start {
+			text := string(source[segments[i-1].Stop:segment.Start])
+			gaps = append(gaps, filters.Gap{Offset: plain.Len(), Text: text, After: strings.ContainsAny(text[:1], "*_~]")})
+		}
+		plain.Write(segment.Value(source))
+	}
+	if strings.TrimSpace(plain.String()) == "" {
+		return string(source[segments[0].Start:segments[len(segments)-1].Stop]), nil
+	}
+
+	result, err := convert(ctx, plain.String())
+	if err != nil {
+		return "", err
+	}
+	return result.InsertGaps(gaps), nil
+}
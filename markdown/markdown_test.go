@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"zundafilter/filters"
+	"zundafilter/filters/filterstest"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expect      string
+		expectError bool
+	}{
+		{
+			name:   "見出しと段落",
+			input:  "# 見出しです\n\nずんだです。\n**もちを食べる**\n",
+			expect: "# 見出しなのだ\n\nずんだなのだ。\n**もちを食べるのだ**\n",
+		},
+		{
+			name:   "front matter",
+			input:  "---\ntitle: です\n---\n\n本文です\n",
+			expect: "---\ntitle: です\n---\n\n本文なのだ\n",
+		},
+		{
+			name: "コード",
+			input: "`です` の説明です\n\n```sh\necho です\n```\n\n    インデントです\n\n" +
+				"<div>HTMLです</div>\n",
+			expect: "`です` の説明なのだ\n\n```sh\necho です\n```\n\n    インデントです\n\n" +
+				"<div>HTMLです</div>\n",
+		},
+		{
+			name:   "リンク",
+			input:  "[リンクです](https://example.com/です) と https://example.com/a_b_c と <https://example.com/です> です\n\n![画像です](a.png)\n",
+			expect: "[リンクなのだ](https://example.com/です) と https://example.com/a_b_c と <https://example.com/です> なのだ\n\n![画像です](a.png)\n",
+		},
+		{
+			name:   "リンクのテキスト",
+			input:  "[ですます](http://example.com/ですます) と [**食べる**](http://example.com/ \"タイトルです\")\n\n[参照です][ref]\n\n[ref]: http://example.com/です \"です\"\n",
+			expect: "[なのだます](http://example.com/ですます) と [**食べるのだ**](http://example.com/ \"タイトルです\")\n\n[参照なのだ][ref]\n\n[ref]: http://example.com/です \"です\"\n",
+		},
+		{
+			name:   "リストと引用",
+			input:  "- 項目です\n  - 入れ子です\n1. 番号です\n\n> 引用です\n> 続きです\n",
+			expect: "- 項目なのだ\n  - 入れ子なのだ\n1. 番号なのだ\n\n> 引用なのだ\n> 続きなのだ\n",
+		},
+		{
+			name:   "表",
+			input:  "| 名前 | 説明 |\n| --- | :---: |\n| ずんだ | 緑です |\n",
+			expect: "| 名前 | 説明 |\n| --- | :---: |\n| ずんだ | 緑なのだ |\n",
+		},
+		{
+			name:        "変換エラー",
+			input:       "はい\n\nerror\n",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := Convert(context.Background(), strings.NewReader(testCase.input), &output, filterstest.ConvertText)
+			if (err != nil) != testCase.expectError {
+				t.Fatalf("Convert() error = %v, expect error %v", err, testCase.expectError)
+			}
+			if err != nil {
+				var blockError *filters.LineError
+				if !errors.As(err, &blockError) || blockError.Line != 3 {
+					t.Fatalf("Convert() error = %v, expect LineError at line 3", err)
+				}
+				return
+			}
+			if output.String() != testCase.expect {
+				t.Fatalf("Convert() = %q, expect %q", output.String(), testCase.expect)
+			}
+		})
+	}
+}
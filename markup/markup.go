@@ -0,0 +1,220 @@
+package markup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"zundafilter/filters"
+
+	"golang.org/x/net/html"
+)
+
+// 変換しない範囲を指定する属性(data-zunda="off")。内側で"on"を指定すると再び変換する
+const OPT_OUT_ATTRIBUTE = "data-zunda"
+
+// 既定で変換しない要素
+var DefaultSkipElements = []string{"script", "style", "code", "pre"}
+
+/*
+* HTML、XMLの表示されるテキストの変換
+* タグ、属性、コメント、文字参照は入力のまま出力する
+ */
+type Converter struct {
+	Convert      filters.ConvertFunc
+	SkipElements []string // 変換しない要素。nilの場合はDefaultSkipElements
+	XML          bool     // XMLとして読み込む(全ての要素を文脈の区切りとし、CDATAは変換しない)
+}
+
+// 文の途中に現れる要素(前後のテキストと合わせて1つの文脈として変換する)
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "cite": true, "code": true, "data": true,
+	"del": true, "dfn": true, "em": true, "font": true, "i": true, "ins": true, "kbd": true, "mark": true,
+	"q": true, "rp": true, "rt": true, "ruby": true, "s": true, "samp": true, "small": true, "span": true,
+	"strong": true, "sub": true, "sup": true, "time": true, "u": true, "var": true,
+}
+
+// 終了タグの無い要素
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true, "input": true,
+	"link": true, "meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// 文字参照(&amp;, &#12354;)
+var characterReferencePattern = regexp.MustCompile(`&(?:[a-zA-Z][a-zA-Z0-9]*|#[0-9]+|#[xX][0-9a-fA-F]+);`)
+
+type element struct {
+	name string
+	skip bool
+}
+
+/*
+* 変換中のテキスト
+* 文脈の区切り(ブロック要素、コメント等)までのテキストと、その間のインライン要素のタグ、変換しない要素
+ */
+type textRun struct {
+	line   int
+	pieces []runPiece
+}
+
+type runPiece struct {
+	raw  string
+	text bool // 変換するテキスト
+}
+
+/*
+* rを読み込んで表示されるテキストのみを変換し、wへ書き込む
+ */
+func (c *Converter) Run(ctx context.Context, r io.Reader, w io.Writer) error {
+	skipElements := map[string]bool{}
+	names := c.SkipElements
+	if names == nil {
+		names = DefaultSkipElements
+	}
+	for _, name := range names {
+		skipElements[strings.ToLower(name)] = true
+	}
+
+	tokenizer := html.NewTokenizer(r)
+	tokenizer.AllowCDATA(c.XML)
+	var output bytes.Buffer
+	stack := []element{}
+	run := textRun{line: 1}
+	line := 1
+	flush := func() error {
+		converted, err := c.convertRun(ctx, run)
+		if err != nil {
+			return &filters.LineError{Line: run.line, Err: err}
+		}
+		output.WriteString(converted)
+		run = textRun{line: line}
+		return nil
+	}
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			if err := tokenizer.Err(); err != io.EOF {
+				return err
+			}
+			break
+		}
+		raw := string(tokenizer.Raw())
+		skipping := len(stack) > 0 && stack[len(stack)-1].skip
+		switch tokenType {
+		case html.TextToken:
+			if strings.HasPrefix(raw, "<![CDATA[") {
+				run.pieces = append(run.pieces, runPiece{raw: raw})
+			} else {
+				run.pieces = append(run.pieces, runPiece{raw: raw, text: !skipping})
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			tagName := string(name)
+			if c.XML {
+				// タグ名の大文字、小文字を区別する
+				tagName = tagNameFromRaw(raw)
+			}
+			inline := !c.XML && inlineElements[tagName]
+			if !inline {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			run.pieces = append(run.pieces, runPiece{raw: raw})
+			if tokenType == html.SelfClosingTagToken || (!c.XML && voidElements[tagName]) {
+				break
+			}
+			skip := skipping || skipElements[strings.ToLower(tagName)]
+			if !skipElements[strings.ToLower(tagName)] {
+				for hasAttr {
+					var key, value []byte
+					key, value, hasAttr = tokenizer.TagAttr()
+					if string(key) == OPT_OUT_ATTRIBUTE {
+						skip = string(value) == "off"
+					}
+				}
+			}
+			stack = append(stack, element{name: tagName, skip: skip})
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tagName := string(name)
+			if c.XML {
+				tagName = tagNameFromRaw(raw)
+			}
+			inline := !c.XML && inlineElements[tagName]
+			if !inline {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			run.pieces = append(run.pieces, runPiece{raw: raw})
+			// 対応する開始タグまで閉じる(閉じタグの省略)
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == tagName {
+					stack = stack[:i]
+					break
+				}
+			}
+		default:
+			// コメント、DOCTYPE
+			if err := flush(); err != nil {
+				return err
+			}
+			run.pieces = append(run.pieces, runPiece{raw: raw})
+		}
+		line += strings.Count(raw, "\n")
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	_, err := output.WriteTo(w)
+	return err
+}
+
+/*
+* XMLのタグ名(Tokenizer#TagNameは小文字にするため、入力から取り出す)
+ */
+func tagNameFromRaw(raw string) string {
+	name := strings.TrimLeft(raw, "</")
+	if end := strings.IndexAny(name, " \t\r\n/>"); end >= 0 {
+		name = name[:end]
+	}
+	return name
+}
+
+/*
+* テキストを連結して変換し、タグ、変換しないテキスト、文字参照を元の位置に戻す
+* 置換された範囲の内側にあった終了タグは置換後の範囲の後ろ、その他は前へ移す
+ */
+func (c *Converter) convertRun(ctx context.Context, run textRun) (string, error) {
+	var plain strings.Builder
+	gaps := []filters.Gap{}
+	for _, piece := range run.pieces {
+		if !piece.text {
+			gaps = append(gaps, filters.Gap{Offset: plain.Len(), Text: piece.raw, After: strings.HasPrefix(piece.raw, "</")})
+			continue
+		}
+		position := 0
+		for _, loc := range characterReferencePattern.FindAllStringIndex(piece.raw, -1) {
+			plain.WriteString(piece.raw[position:loc[0]])
+			gaps = append(gaps, filters.Gap{Offset: plain.Len(), Text: piece.raw[loc[0]:loc[1]]})
+			position = loc[1]
+		}
+		plain.WriteString(piece.raw[position:])
+	}
+	if strings.TrimSpace(plain.String()) == "" {
+		var raw strings.Builder
+		for _, piece := range run.pieces {
+			raw.WriteString(piece.raw)
+		}
+		return raw.String(), nil
+	}
+
+	result, err := c.Convert(ctx, plain.String())
+	if err != nil {
+		return "", err
+	}
+	return result.InsertGaps(gaps), nil
+}
@@ -0,0 +1,82 @@
+package markup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"zundafilter/filters"
+	"zundafilter/filters/filterstest"
+)
+
+func TestConverterRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		xml          bool
+		skipElements []string
+		input        string
+		expect       string
+		expectError  bool
+	}{
+		{
+			name: "HTML",
+			input: "<!DOCTYPE html>\n<HTML><head><title>題です</title><style>p::after { content: \"です\" }</style></head>\n" +
+				"<body><p class=\"です\">ずんだ<B>もちを食べる</B>&amp;お茶です<br>次です</p><!-- です --></body></HTML>\n",
+			expect: "<!DOCTYPE html>\n<HTML><head><title>題なのだ</title><style>p::after { content: \"です\" }</style></head>\n" +
+				"<body><p class=\"です\">ずんだ<B>もちを食べるのだ</B>&amp;お茶なのだ<br>次なのだ</p><!-- です --></body></HTML>\n",
+		},
+		{
+			name:   "変換しない要素",
+			input:  "<p>実行は<code>です</code>です</p><pre>整形です</pre><script>var s = \"です\";</script>\n",
+			expect: "<p>実行は<code>です</code>なのだ</p><pre>整形です</pre><script>var s = \"です\";</script>\n",
+		},
+		{
+			name:   "data-zunda",
+			input:  "<div data-zunda=\"off\"><p>原文です</p><p data-zunda=\"on\">本文です</p></div><p>本文です</p>",
+			expect: "<div data-zunda=\"off\"><p>原文です</p><p data-zunda=\"on\">本文なのだ</p></div><p>本文なのだ</p>",
+		},
+		{
+			name:         "変換しない要素の指定",
+			skipElements: []string{"p"},
+			input:        "<p>原文です</p><code>コードです</code>",
+			expect:       "<p>原文です</p><code>コードなのだ</code>",
+		},
+		{
+			name:   "XML",
+			xml:    true,
+			input:  "<?xml version=\"1.0\"?>\n<resources>\n  <string name=\"です\">挨拶です</string>\n  <Item><![CDATA[原文です]]></Item>\n  <empty/>\n</resources>\n",
+			expect: "<?xml version=\"1.0\"?>\n<resources>\n  <string name=\"です\">挨拶なのだ</string>\n  <Item><![CDATA[原文です]]></Item>\n  <empty/>\n</resources>\n",
+		},
+		{
+			name:        "変換エラー",
+			input:       "<p>はい</p>\n\n<p>error</p>",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			converter := Converter{
+				Convert:      filterstest.ConvertText,
+				SkipElements: testCase.skipElements,
+				XML:          testCase.xml,
+			}
+			var output bytes.Buffer
+			err := converter.Run(context.Background(), strings.NewReader(testCase.input), &output)
+			if (err != nil) != testCase.expectError {
+				t.Fatalf("Converter.Run() error = %v, expect error %v", err, testCase.expectError)
+			}
+			if err != nil {
+				var lineError *filters.LineError
+				if !errors.As(err, &lineError) || lineError.Line != 3 {
+					t.Fatalf("Converter.Run() error = %v, expect LineError at line 3", err)
+				}
+				return
+			}
+			if output.String() != testCase.expect {
+				t.Fatalf("Converter.Run() = %q, expect %q", output.String(), testCase.expect)
+			}
+		})
+	}
+}
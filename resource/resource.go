@@ -0,0 +1,137 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	APP_NAME = "zundafilter"
+	DATA_DIR = "data" // 実行ファイルの配置ディレクトリ内のデータディレクトリ
+)
+
+/*
+* リソースの種類
+* XDG Base Directoryのどのディレクトリを探すかが異なる
+ */
+type Kind int
+
+const (
+	KindConfig Kind = iota // $XDG_CONFIG_HOME, $XDG_CONFIG_DIRS
+	KindData               // $XDG_DATA_HOME, $XDG_DATA_DIRS
+)
+
+/*
+* リソースが見つからない
+* errors.Is(err, ErrNotFound)で判定する
+ */
+var ErrNotFound = errors.New("resource not found")
+
+type NotFoundError struct {
+	Name     string
+	Searched []string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s is not found in %s", e.Name, strings.Join(e.Searched, ", "))
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+/*
+* リソースの探索
+* 1. flagValue(コマンドラインで指定されたパス)
+* 2. 環境変数envName
+* 3. XDG Base Directory(<dir>/zundafilter/<name>)
+* 4. 実行ファイルの配置ディレクトリ(<bin dir>/data/<name>)
+* 1, 2は指定されていれば存在しなくてもそのパスを返す(開く際にエラーとなる)
+* 3, 4は存在するパスのみを返し、いずれも無い場合はNotFoundErrorとする
+ */
+func Resolve(kind Kind, name string, flagValue string, envName string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if envName != "" {
+		if path := os.Getenv(envName); path != "" {
+			return path, nil
+		}
+	}
+	candidates := Candidates(kind, name)
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", &NotFoundError{
+		Name:     name,
+		Searched: candidates,
+	}
+}
+
+/*
+* 探索するパスの一覧(優先順)
+ */
+func Candidates(kind Kind, name string) []string {
+	candidates := []string{}
+	for _, dir := range xdgDirs(kind) {
+		candidates = append(candidates, filepath.Join(dir, APP_NAME, name))
+	}
+	if path, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(path), DATA_DIR, name))
+	}
+	return candidates
+}
+
+/*
+* 新規作成する場合のパス
+* $XDG_CONFIG_HOME/zundafilter/<name> または $XDG_DATA_HOME/zundafilter/<name>
+ */
+func UserPath(kind Kind, name string) (string, error) {
+	dir, err := xdgHome(kind)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, APP_NAME, name), nil
+}
+
+func xdgHome(kind Kind) (string, error) {
+	envName, defaultDir := "XDG_CONFIG_HOME", ".config"
+	if kind == KindData {
+		envName, defaultDir = "XDG_DATA_HOME", filepath.Join(".local", "share")
+	}
+	// 仕様上、絶対パスでない値は無視する
+	if dir := os.Getenv(envName); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, defaultDir), nil
+}
+
+func xdgDirs(kind Kind) []string {
+	dirs := []string{}
+	if home, err := xdgHome(kind); err == nil {
+		dirs = append(dirs, home)
+	}
+	envName, defaultDirs := "XDG_CONFIG_DIRS", "/etc/xdg"
+	if kind == KindData {
+		envName, defaultDirs = "XDG_DATA_DIRS", "/usr/local/share:/usr/share"
+	}
+	value := os.Getenv(envName)
+	if value == "" {
+		value = defaultDirs
+	}
+	for _, dir := range filepath.SplitList(value) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	configHome := filepath.Join(root, "config")
+	configDir := filepath.Join(root, "etc")
+	dataHome := filepath.Join(root, "data")
+	for _, path := range []string{
+		filepath.Join(configHome, APP_NAME, "home.yaml"),
+		filepath.Join(configHome, APP_NAME, "both.yaml"),
+		filepath.Join(configDir, APP_NAME, "both.yaml"),
+		filepath.Join(configDir, APP_NAME, "dirs.yaml"),
+		filepath.Join(dataHome, APP_NAME, "zunda.db"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_CONFIG_DIRS", configDir)
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("XDG_DATA_DIRS", filepath.Join(root, "share"))
+	t.Setenv("TEST_RESOURCE", "/env/zunda.db")
+
+	tests := []struct {
+		name        string
+		kind        Kind
+		fileName    string
+		flagValue   string
+		envName     string
+		expect      string
+		expectError error
+	}{
+		{
+			name:      "フラグ優先",
+			kind:      KindData,
+			fileName:  "zunda.db",
+			flagValue: "/flag/zunda.db",
+			envName:   "TEST_RESOURCE",
+			expect:    "/flag/zunda.db",
+		},
+		{
+			name:     "環境変数",
+			kind:     KindData,
+			fileName: "zunda.db",
+			envName:  "TEST_RESOURCE",
+			expect:   "/env/zunda.db",
+		},
+		{
+			name:     "XDG_DATA_HOME",
+			kind:     KindData,
+			fileName: "zunda.db",
+			envName:  "TEST_RESOURCE_UNSET",
+			expect:   filepath.Join(dataHome, APP_NAME, "zunda.db"),
+		},
+		{
+			name:     "XDG_CONFIG_HOMEがXDG_CONFIG_DIRSより優先",
+			kind:     KindConfig,
+			fileName: "both.yaml",
+			expect:   filepath.Join(configHome, APP_NAME, "both.yaml"),
+		},
+		{
+			name:     "XDG_CONFIG_DIRS",
+			kind:     KindConfig,
+			fileName: "dirs.yaml",
+			expect:   filepath.Join(configDir, APP_NAME, "dirs.yaml"),
+		},
+		{
+			name:        "見つからない",
+			kind:        KindConfig,
+			fileName:    "zunda.db",
+			expectError: ErrNotFound,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := Resolve(testCase.kind, testCase.fileName, testCase.flagValue, testCase.envName)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("Resolve() error = %v, expect %v", err, testCase.expectError)
+			}
+			if actual != testCase.expect {
+				t.Fatalf("Resolve() = %v, expect %v", actual, testCase.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package subtitle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"zundafilter/filters"
+)
+
+/*
+* 字幕の形式
+ */
+type Format string
+
+const (
+	FormatSrt Format = "srt" // SubRip
+	FormatVtt Format = "vtt" // WebVTT
+	FormatAss Format = "ass" // Advanced SubStation Alpha(.ssaを含む)
+)
+
+/*
+* ファイル名の拡張子から形式を判定する
+* return: 字幕の形式、字幕ではない場合はfalse
+ */
+func FormatFromPath(path string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt":
+		return FormatSrt, true
+	case ".vtt":
+		return FormatVtt, true
+	case ".ass", ".ssa":
+		return FormatAss, true
+	default:
+		return "", false
+	}
+}
+
+/*
+* 形式名(srt, vtt, ass)の解析
+ */
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case FormatSrt, FormatVtt, FormatAss:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown subtitle format: %s (available: %s, %s, %s)", name, FormatSrt, FormatVtt, FormatAss)
+	}
+}
+
+// SRT、WebVTTの装飾タグ(<i>, <c.name>, <v Name>, <00:00:01.000>)、SRTのASS形式の指定({\an8})、文字参照
+var cueTagPattern = regexp.MustCompile(`<[^>\n]*>|\{\\[^}\n]*\}|&(?:[a-zA-Z]+|#[0-9]+|#x[0-9a-fA-F]+);`)
+
+// ASSのオーバーライドブロック({\i1})、改行しない空白(\h)
+var assTagPattern = regexp.MustCompile(`\{[^}]*\}|\\h`)
+
+// ASSの改行(\N, \n)
+var assLineBreakPattern = regexp.MustCompile(`\\[Nn]`)
+
+/*
+* 字幕を読み込んで台詞のみを変換し、wへ書き込む
+* 番号、時間、装飾タグ、ASSのオーバーライドブロック、改行コードは変換前のまま出力する
+* 複数行の台詞は1行ずつ変換する
+ */
+func Convert(ctx context.Context, format Format, r io.Reader, w io.Writer, convert filters.ConvertFunc) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	var converted []string
+	switch format {
+	case FormatSrt, FormatVtt:
+		converted, err = convertCues(ctx, lines, convert)
+	case FormatAss:
+		converted, err = convertAss(ctx, lines, convert)
+	default:
+		return fmt.Errorf("unknown subtitle format: %s", format)
+	}
+	if err != nil {
+		return err
+	}
+	var buffer bytes.Buffer
+	for _, line := range converted {
+		buffer.WriteString(line)
+	}
+	_, err = buffer.WriteTo(w)
+	return err
+}
+
+/*
+* 行末の改行コードの分離
+ */
+func splitLineEnding(line string) (string, string) {
+	content := strings.TrimRight(line, "\r\n")
+	return content, line[len(content):]
+}
+
+/*
+* SRT、WebVTTの変換
+* 空行で区切られたブロックのうち、時間の行(00:00:01,000 --> 00:00:02,000)に続く行を台詞とする
+* WebVTTのヘッダ、NOTE、STYLE、REGIONのブロックは時間の行を含まないため変換しない
+ */
+func convertCues(ctx context.Context, lines []string, convert filters.ConvertFunc) ([]string, error) {
+	converted := make([]string, len(lines))
+	inCue := false
+	for i, line := range lines {
+		content, ending := splitLineEnding(line)
+		switch {
+		case strings.TrimSpace(content) == "":
+			inCue = false
+		case !inCue && strings.Contains(content, "-->"):
+			inCue = true
+		case inCue:
+			text, err := convertTaggedText(ctx, content, cueTagPattern, convert)
+			if err != nil {
+				return nil, &filters.LineError{Line: i + 1, Err: err}
+			}
+			content = text
+		}
+		converted[i] = content + ending
+	}
+	return converted, nil
+}
+
+/*
+* ASSの変換
+* [Events]のDialogueの行のText(Formatの行で指定された位置、既定は最後の10番目)のみを変換する
+ */
+func convertAss(ctx context.Context, lines []string, convert filters.ConvertFunc) ([]string, error) {
+	converted := make([]string, len(lines))
+	inEvents := false
+	textIndex, fields := 9, 10
+	for i, line := range lines {
+		converted[i] = line
+		content, ending := splitLineEnding(line)
+		trimmed := strings.TrimSpace(content)
+		if strings.HasPrefix(trimmed, "[") {
+			inEvents = strings.EqualFold(trimmed, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+		name, value, ok := strings.Cut(content, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(name) {
+		case "Format":
+			names := strings.Split(value, ",")
+			fields = len(names)
+			for index, fieldName := range names {
+				if strings.TrimSpace(fieldName) == "Text" {
+					textIndex = index
+				}
+			}
+		case "Dialogue":
+			// Textが最後の項目ではない場合は項目を区切れないため変換しない
+			if textIndex != fields-1 {
+				continue
+			}
+			// Text以外の項目はカンマを含まないため、Textの前のカンマの位置までをそのまま残す
+			offset := len(name) + 1
+			rest := value
+			for field := 0; field < textIndex; field++ {
+				comma := strings.Index(rest, ",")
+				if comma < 0 {
+					return nil, &filters.LineError{Line: i + 1, Err: fmt.Errorf("dialogue has less than %d fields", fields)}
+				}
+				offset += comma + 1
+				rest = rest[comma+1:]
+			}
+			text, err := convertAssText(ctx, rest, convert)
+			if err != nil {
+				return nil, &filters.LineError{Line: i + 1, Err: err}
+			}
+			converted[i] = content[:offset] + text + ending
+		}
+	}
+	return converted, nil
+}
+
+/*
+* ASSの台詞の変換(改行\Nで区切った行ごとに変換する)
+ */
+func convertAssText(ctx context.Context, text string, convert filters.ConvertFunc) (string, error) {
+	var converted strings.Builder
+	position := 0
+	for _, loc := range append(assLineBreakPattern.FindAllStringIndex(text, -1), []int{len(text), len(text)}) {
+		line, err := convertTaggedText(ctx, text[position:loc[0]], assTagPattern, convert)
+		if err != nil {
+			return "", err
+		}
+		converted.WriteString(line)
+		converted.WriteString(text[loc[0]:loc[1]])
+		position = loc[1]
+	}
+	return converted.String(), nil
+}
+
+/*
+* タグを除いたテキストを変換し、タグを元の位置に戻す
+* 置換された範囲の内側にあったタグは、閉じタグは置換後の範囲の後ろ、その他は前へ移す
+ */
+func convertTaggedText(ctx context.Context, text string, tagPattern *regexp.Regexp, convert filters.ConvertFunc) (string, error) {
+	var plain strings.Builder
+	tags := []filters.Gap{}
+	position := 0
+	for _, loc := range tagPattern.FindAllStringIndex(text, -1) {
+		plain.WriteString(text[position:loc[0]])
+		tag := text[loc[0]:loc[1]]
+		tags = append(tags, filters.Gap{Offset: plain.Len(), Text: tag, After: strings.HasPrefix(tag, "</")})
+		position = loc[1]
+	}
+	plain.WriteString(text[position:])
+	if strings.TrimSpace(plain.String()) == "" {
+		return text, nil
+	}
+
+	result, err := convert(ctx, plain.String())
+	if err != nil {
+		return text, err
+	}
+	return result.InsertGaps(tags), nil
+}
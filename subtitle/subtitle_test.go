@@ -0,0 +1,90 @@
+package subtitle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"zundafilter/filters"
+	"zundafilter/filters/filterstest"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      Format
+		input       string
+		expect      string
+		expectError bool
+	}{
+		{
+			name:   "SRT",
+			format: FormatSrt,
+			input: "1\r\n00:00:01,000 --> 00:00:02,000\r\nずんだです\r\n<i>もちを食べる</i>\r\n\r\n" +
+				"2\r\n00:00:03,000 --> 00:00:04,500\r\n{\\an8}<font color=\"#00ff00\">緑です</font>\r\n",
+			expect: "1\r\n00:00:01,000 --> 00:00:02,000\r\nずんだなのだ\r\n<i>もちを食べるのだ</i>\r\n\r\n" +
+				"2\r\n00:00:03,000 --> 00:00:04,500\r\n{\\an8}<font color=\"#00ff00\">緑なのだ</font>\r\n",
+		},
+		{
+			name:   "置換範囲の内側のタグ",
+			format: FormatSrt,
+			input:  "1\n00:00:01,000 --> 00:00:02,000\nで<b>す</b>\n",
+			expect: "1\n00:00:01,000 --> 00:00:02,000\n<b>なのだ</b>\n",
+		},
+		{
+			name:   "複数行の台詞",
+			format: FormatSrt,
+			input:  "1\r\n00:00:01,000 --> 00:00:02,000\r\nずんだです。もちを\r\n食べる\r\nで\r\nす\r\n",
+			expect: "1\r\n00:00:01,000 --> 00:00:02,000\r\nずんだなのだ。もちを\r\n食べるのだ\r\nで\r\nす\r\n",
+		},
+		{
+			name:   "WebVTT",
+			format: FormatVtt,
+			input: "WEBVTT - です\n\nNOTE です\n\nSTYLE\n::cue { color: white }\n\n" +
+				"intro-です\n00:01.000 --> 00:02.000 align:start\n<v ずんだもん>ずんだです</v>\n<c.yellow>食べる</c> &amp; <00:01.500>です\n",
+			expect: "WEBVTT - です\n\nNOTE です\n\nSTYLE\n::cue { color: white }\n\n" +
+				"intro-です\n00:01.000 --> 00:02.000 align:start\n<v ずんだもん>ずんだなのだ</v>\n<c.yellow>食べるのだ</c> &amp; <00:01.500>なのだ\n",
+		},
+		{
+			name:   "ASS",
+			format: FormatAss,
+			input: "[Script Info]\nTitle: です\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n[Events]\n" +
+				"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: 0,0:00:01.00,0:00:02.00,Default,ずんだもん,0,0,0,,{\\i1}ずんだです{\\i0}\\N食べる,です\n" +
+				"Dialogue: 0,0:00:03.00,0:00:04.00,Default,ずんだもん,0,0,0,,ずんだで\\Nす\n" +
+				"Comment: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,です\n",
+			expect: "[Script Info]\nTitle: です\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n[Events]\n" +
+				"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: 0,0:00:01.00,0:00:02.00,Default,ずんだもん,0,0,0,,{\\i1}ずんだなのだ{\\i0}\\N食べるのだ,なのだ\n" +
+				"Dialogue: 0,0:00:03.00,0:00:04.00,Default,ずんだもん,0,0,0,,ずんだで\\Nす\n" +
+				"Comment: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,です\n",
+		},
+		{
+			name:        "変換エラー",
+			format:      FormatSrt,
+			input:       "1\n00:00:01,000 --> 00:00:02,000\nerror\n",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			var output bytes.Buffer
+			err := Convert(context.Background(), testCase.format, strings.NewReader(testCase.input), &output, filterstest.ConvertText)
+			if (err != nil) != testCase.expectError {
+				t.Fatalf("Convert() error = %v, expect error %v", err, testCase.expectError)
+			}
+			if err != nil {
+				var lineError *filters.LineError
+				if !errors.As(err, &lineError) || lineError.Line != 3 {
+					t.Fatalf("Convert() error = %v, expect LineError at line 3", err)
+				}
+				return
+			}
+			if output.String() != testCase.expect {
+				t.Fatalf("Convert() = %q, expect %q", output.String(), testCase.expect)
+			}
+		})
+	}
+}
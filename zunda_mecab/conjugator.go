@@ -0,0 +1,283 @@
+package zunda_mecab
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 五段活用の行毎の語尾
+type godanEnding struct {
+	a     string // 未然形
+	i     string // 連用形
+	u     string // 基本形
+	e     string // 仮定形, 命令ｅ
+	o     string // 未然ウ接続
+	onbin string // 連用タ接続(音便が無い場合は空)
+}
+
+var godanEndings = map[MecabConjugationType]godanEnding{
+	MecabConjugationTypeGodanKaIOnbin:    {a: "か", i: "き", u: "く", e: "け", o: "こ", onbin: "い"},
+	MecabConjugationTypeGodanKaSokuOnbin: {a: "か", i: "き", u: "く", e: "け", o: "こ", onbin: "っ"},
+	MecabConjugationTypeGodanKaYuku:      {a: "か", i: "き", u: "く", e: "け", o: "こ", onbin: "っ"},
+	MecabConjugationTypeGodanGa:          {a: "が", i: "ぎ", u: "ぐ", e: "げ", o: "ご", onbin: "い"},
+	MecabConjugationTypeGodanSa:          {a: "さ", i: "し", u: "す", e: "せ", o: "そ"},
+	MecabConjugationTypeGodanTa:          {a: "た", i: "ち", u: "つ", e: "て", o: "と", onbin: "っ"},
+	MecabConjugationTypeGodanNa:          {a: "な", i: "に", u: "ぬ", e: "ね", o: "の", onbin: "ん"},
+	MecabConjugationTypeGodanBa:          {a: "ば", i: "び", u: "ぶ", e: "べ", o: "ぼ", onbin: "ん"},
+	MecabConjugationTypeGodanMa:          {a: "ま", i: "み", u: "む", e: "め", o: "も", onbin: "ん"},
+	MecabConjugationTypeGodanRa:          {a: "ら", i: "り", u: "る", e: "れ", o: "ろ", onbin: "っ"},
+	MecabConjugationTypeGodanRaAru:       {a: "ら", i: "り", u: "る", e: "れ", o: "ろ", onbin: "っ"},
+	MecabConjugationTypeGodanRaSp:        {a: "ら", i: "り", u: "る", e: "れ", o: "ろ", onbin: "っ"},
+	MecabConjugationTypeGodanWaSokuOnbin: {a: "わ", i: "い", u: "う", e: "え", o: "お", onbin: "っ"},
+	MecabConjugationTypeGodanWaUOnbin:    {a: "わ", i: "い", u: "う", e: "え", o: "お", onbin: "う"},
+}
+
+// サ変の語幹を除いた活用語尾(スル, −スル)
+var sahenSuruEndings = map[MecabConjugationForm]string{
+	MecabConjugationFormKihon:               "する",
+	MecabConjugationFormBungoKihon:          "す",
+	MecabConjugationFormMizen:               "し",
+	MecabConjugationFormMizenReruSetsuzoku:  "さ",
+	MecabConjugationFormMizenNuSetsuzoku:    "せ",
+	MecabConjugationFormMizenUSetsuzoku:     "しよ",
+	MecabConjugationFormRenyou:              "し",
+	MecabConjugationFormKatei:               "すれ",
+	MecabConjugationFormKateiShukuYaku1:     "すりゃ",
+	MecabConjugationFormMeireiRo:            "しろ",
+	MecabConjugationFormMeireiYo:            "せよ",
+	MecabConjugationFormTaigenSeatsuzokuSp:  "すん",
+	MecabConjugationFormTaigenSeatsuzokuSp2: "す",
+}
+
+// サ変(−ズル)の活用語尾
+var sahenZuruEndings = map[MecabConjugationForm]string{
+	MecabConjugationFormKihon:            "ずる",
+	MecabConjugationFormBungoKihon:       "ず",
+	MecabConjugationFormMizen:            "じ",
+	MecabConjugationFormMizenNuSetsuzoku: "ぜ",
+	MecabConjugationFormMizenUSetsuzoku:  "じよ",
+	MecabConjugationFormRenyou:           "じ",
+	MecabConjugationFormKatei:            "ずれ",
+	MecabConjugationFormMeireiYo:         "ぜよ",
+}
+
+// 一段の語幹(「る」を除く)に続く活用語尾
+var ichidanEndings = map[MecabConjugationForm]string{
+	MecabConjugationFormKihon:              "る",
+	MecabConjugationFormMizen:              "",
+	MecabConjugationFormMizenUSetsuzoku:    "よ",
+	MecabConjugationFormRenyou:             "",
+	MecabConjugationFormKatei:              "れ",
+	MecabConjugationFormKateiShukuYaku1:    "りゃ",
+	MecabConjugationFormMeireiRo:           "ろ",
+	MecabConjugationFormMeireiYo:           "よ",
+	MecabConjugationFormTaigenSeatsuzokuSp: "ん",
+}
+
+// カ変の活用語尾。漢字表記(来ル)は語幹が変化しないため「来」に続く送り仮名のみ
+var kahenKuruKanjiEndings = map[MecabConjugationForm]string{
+	MecabConjugationFormKihon:              "来る",
+	MecabConjugationFormMizen:              "来",
+	MecabConjugationFormMizenUSetsuzoku:    "来よ",
+	MecabConjugationFormRenyou:             "来",
+	MecabConjugationFormKatei:              "来れ",
+	MecabConjugationFormKateiShukuYaku1:    "来りゃ",
+	MecabConjugationFormMeireiI:            "来い",
+	MecabConjugationFormMeireiYo:           "来よ",
+	MecabConjugationFormTaigenSeatsuzokuSp: "来ん",
+}
+var kahenKuruEndings = map[MecabConjugationForm]string{
+	MecabConjugationFormKihon:              "くる",
+	MecabConjugationFormMizen:              "こ",
+	MecabConjugationFormMizenUSetsuzoku:    "こよ",
+	MecabConjugationFormRenyou:             "き",
+	MecabConjugationFormKatei:              "くれ",
+	MecabConjugationFormKateiShukuYaku1:    "くりゃ",
+	MecabConjugationFormMeireiI:            "こい",
+	MecabConjugationFormMeireiYo:           "こよ",
+	MecabConjugationFormTaigenSeatsuzokuSp: "くん",
+}
+
+// 形容詞の語幹(「い」を除く)に続く活用語尾。連用ゴザイ接続は語幹が変化するため別に扱う
+var adjectiveEndings = map[MecabConjugationForm]string{
+	MecabConjugationFormKihon:             "い",
+	MecabConjugationFormBungoKihon:        "し",
+	MecabConjugationFormGaruSetsuzoku:     "",
+	MecabConjugationFormTaigenSetsuzoku:   "き",
+	MecabConjugationFormMizenNuSetsuzoku:  "から",
+	MecabConjugationFormMizenUSetsuzoku:   "かろ",
+	MecabConjugationFormRenyouTaSetsuzoku: "かっ",
+	MecabConjugationFormRenyouTeSetsuzoku: "く",
+	MecabConjugationFormKatei:             "けれ",
+	MecabConjugationFormKateiShukuYaku1:   "けりゃ",
+	MecabConjugationFormKateiShukuYaku2:   "きゃ",
+	MecabConjugationFormMeireiE:           "かれ",
+}
+
+// 形容詞(アウオ段)の連用ゴザイ接続で語幹末尾のア段をオ段にする
+var adjectiveAuoGozai = map[string]string{
+	"か": "こ", "が": "ご", "さ": "そ", "ざ": "ぞ", "た": "と", "だ": "ど",
+	"な": "の", "は": "ほ", "ば": "ぼ", "ぱ": "ぽ", "ま": "も", "や": "よ",
+	"ら": "ろ", "わ": "お",
+}
+
+/*
+* 原形と活用型から活用形を生成する
+* IPADICの活用表に倣う。対応していない活用型・活用形はErrNotFoundとなる
+* ex) 書く, 五段・カ行イ音便, 連用タ接続 -> 書い
+ */
+func Conjugate(originalForm string, conjugationType MecabConjugationType, conjugationForm MecabConjugationForm) (string, error) {
+	notFound := &NotFoundError{
+		Table: "Conjugate",
+		Key:   fmt.Sprintf("%s(%s, %s)", originalForm, conjugationType, conjugationForm),
+	}
+
+	if ending, ok := godanEndings[conjugationType]; ok {
+		stem, ok := trimSuffix(originalForm, ending.u)
+		if !ok {
+			return "", notFound
+		}
+		word, ok := conjugateGodan(stem, conjugationType, ending, conjugationForm)
+		if !ok {
+			return "", notFound
+		}
+		return word, nil
+	}
+
+	var stem string
+	var endings map[MecabConjugationForm]string
+	switch conjugationType {
+	case MecabConjugationTypeIchidan:
+		stem, endings = strings.TrimSuffix(originalForm, "る"), ichidanEndings
+		if stem == originalForm || stem == "" {
+			return "", notFound
+		}
+	case MecabConjugationTypeKahenKuruKanji:
+		stem, endings = strings.TrimSuffix(originalForm, "来る"), kahenKuruKanjiEndings
+	case MecabConjugationTypeKahenKuru:
+		stem, endings = strings.TrimSuffix(originalForm, "くる"), kahenKuruEndings
+	case MecabConjugationTypeSahenSuru, MecabConjugationTypeSahenSuffixSuru:
+		stem, endings = strings.TrimSuffix(originalForm, "する"), sahenSuruEndings
+	case MecabConjugationTypeSahenSuffixZuru:
+		stem, endings = strings.TrimSuffix(originalForm, "ずる"), sahenZuruEndings
+	case MecabConjugationTypeI, MecabConjugationTypeAuo:
+		stem, endings = strings.TrimSuffix(originalForm, "い"), adjectiveEndings
+		if stem == originalForm || stem == "" {
+			return "", notFound
+		}
+		if conjugationForm == MecabConjugationFormRenyouGozaiSetsuzoku {
+			return conjugateAdjectiveGozai(stem, conjugationType), nil
+		}
+		// シク活用は語幹が「し」で終わる ex) 美しい -> 美し
+		if conjugationForm == MecabConjugationFormBungoKihon && strings.HasSuffix(stem, "し") {
+			return stem, nil
+		}
+	default:
+		return "", notFound
+	}
+	if stem+endings[MecabConjugationFormKihon] != originalForm {
+		return "", notFound
+	}
+	ending, ok := endings[conjugationForm]
+	if !ok {
+		return "", notFound
+	}
+	return stem + ending, nil
+}
+
+func conjugateGodan(stem string, conjugationType MecabConjugationType, ending godanEnding, conjugationForm MecabConjugationForm) (string, bool) {
+	switch conjugationForm {
+	case MecabConjugationFormKihon:
+		return stem + ending.u, true
+	case MecabConjugationFormMizen:
+		return stem + ending.a, true
+	case MecabConjugationFormMizenUSetsuzoku:
+		return stem + ending.o, true
+	case MecabConjugationFormRenyou:
+		return stem + ending.i, true
+	case MecabConjugationFormRenyouTaSetsuzoku:
+		if ending.onbin == "" {
+			return "", false
+		}
+		return stem + ending.onbin, true
+	case MecabConjugationFormKatei:
+		return stem + ending.e, true
+	case MecabConjugationFormMeireiE:
+		if conjugationType == MecabConjugationTypeGodanRaSp {
+			return "", false
+		}
+		return stem + ending.e, true
+	case MecabConjugationFormMeireiI:
+		if conjugationType != MecabConjugationTypeGodanRaSp {
+			return "", false
+		}
+		return stem + "い", true
+	case MecabConjugationFormKateiShukuYaku1:
+		if conjugationType == MecabConjugationTypeGodanRaSp {
+			return "", false
+		}
+		if ending.u == "う" {
+			return stem + "や", true
+		}
+		return stem + ending.i + "ゃ", true
+	case MecabConjugationFormMizenSp:
+		if ending.u != "る" {
+			return "", false
+		}
+		return stem + "ん", true
+	default:
+		return "", false
+	}
+}
+
+func conjugateAdjectiveGozai(stem string, conjugationType MecabConjugationType) string {
+	if conjugationType == MecabConjugationTypeI {
+		// ex) 美し -> 美しゅう
+		return stem + "ゅう"
+	}
+	// ex) あか -> あこう, 赤 -> 赤う
+	runes := []rune(stem)
+	last := string(runes[len(runes)-1])
+	if replaced, ok := adjectiveAuoGozai[last]; ok {
+		return string(runes[:len(runes)-1]) + replaced + "う"
+	}
+	return stem + "う"
+}
+
+func trimSuffix(word string, suffix string) (string, bool) {
+	if !strings.HasSuffix(word, suffix) || word == suffix {
+		return "", false
+	}
+	return strings.TrimSuffix(word, suffix), true
+}
+
+/*
+* 活用形の生成によるZundaDbController
+* zunda.dbを必要としない
+ */
+type ConjugatorRepository struct{}
+
+func (c ConjugatorRepository) SelectConjugation(baseWord string, conjugationType MecabConjugationType, conjugationForm MecabConjugationForm) (ConjugationRow, error) {
+	word, err := Conjugate(baseWord, conjugationType, conjugationForm)
+	if err != nil {
+		return ConjugationRow{}, err
+	}
+	wordType := MecabWordTypeVerb
+	if conjugationType == MecabConjugationTypeI || conjugationType == MecabConjugationTypeAuo {
+		wordType = MecabWordTypeAdjective
+	}
+	return ConjugationRow{
+		BaseWord:        baseWord,
+		WordType:        wordType,
+		ConjugationType: conjugationType,
+		ConjugationForm: conjugationForm,
+		Word:            word,
+	}, nil
+}
+
+/*
+* 置換辞書はzunda.dbにのみ存在するため常に空
+ */
+func (c ConjugatorRepository) SelectReplacements() ([]ReplacementRow, error) {
+	return []ReplacementRow{}, nil
+}
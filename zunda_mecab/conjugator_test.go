@@ -0,0 +1,138 @@
+package zunda_mecab
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConjugate(t *testing.T) {
+	tests := []struct {
+		name            string
+		originalForm    string
+		conjugationType MecabConjugationType
+		conjugationForm MecabConjugationForm
+		expect          string
+		expectError     error
+	}{
+		{
+			name:            "五段-未然形",
+			originalForm:    "渡す",
+			conjugationType: MecabConjugationTypeGodanSa,
+			conjugationForm: MecabConjugationFormMizen,
+			expect:          "渡さ",
+		},
+		{
+			name:            "五段-イ音便",
+			originalForm:    "書く",
+			conjugationType: MecabConjugationTypeGodanKaIOnbin,
+			conjugationForm: MecabConjugationFormRenyouTaSetsuzoku,
+			expect:          "書い",
+		},
+		{
+			name:            "五段-撥音便",
+			originalForm:    "読む",
+			conjugationType: MecabConjugationTypeGodanMa,
+			conjugationForm: MecabConjugationFormRenyouTaSetsuzoku,
+			expect:          "読ん",
+		},
+		{
+			name:            "五段-サ行は音便なし",
+			originalForm:    "話す",
+			conjugationType: MecabConjugationTypeGodanSa,
+			conjugationForm: MecabConjugationFormRenyouTaSetsuzoku,
+			expectError:     ErrNotFound,
+		},
+		{
+			name:            "一段-命令ｒｏ",
+			originalForm:    "集める",
+			conjugationType: MecabConjugationTypeIchidan,
+			conjugationForm: MecabConjugationFormMeireiRo,
+			expect:          "集めろ",
+		},
+		{
+			name:            "サ変-未然形",
+			originalForm:    "勉強する",
+			conjugationType: MecabConjugationTypeSahenSuffixSuru,
+			conjugationForm: MecabConjugationFormMizen,
+			expect:          "勉強し",
+		},
+		{
+			name:            "形容詞-連用タ接続",
+			originalForm:    "美しい",
+			conjugationType: MecabConjugationTypeI,
+			conjugationForm: MecabConjugationFormRenyouTaSetsuzoku,
+			expect:          "美しかっ",
+		},
+		{
+			name:            "活用型と原形の不一致",
+			originalForm:    "書く",
+			conjugationType: MecabConjugationTypeGodanMa,
+			conjugationForm: MecabConjugationFormMizen,
+			expectError:     ErrNotFound,
+		},
+		{
+			name:            "活用型なし",
+			originalForm:    "書く",
+			conjugationType: MecabConjugationTypeNone,
+			conjugationForm: MecabConjugationFormMizen,
+			expectError:     ErrNotFound,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := Conjugate(testCase.originalForm, testCase.conjugationType, testCase.conjugationForm)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("Conjugate() error = %v, expect %v", err, testCase.expectError)
+			}
+			if actual != testCase.expect {
+				t.Fatalf("Conjugate() = %v, expect %v", actual, testCase.expect)
+			}
+		})
+	}
+}
+
+/*
+* IPADICの活用行との比較
+* 同じ原形・活用型・活用形に複数の表記がある場合(ex: なさり/なさい)はいずれかに一致すればよい
+ */
+func TestConjugateIpadic(t *testing.T) {
+	builder := ZundaDbBuilder{
+		IpadicDir: "testdata/ipadic",
+	}
+	type conjugationKey struct {
+		originalForm    string
+		conjugationType MecabConjugationType
+		conjugationForm MecabConjugationForm
+	}
+	expects := map[conjugationKey][]string{}
+	for _, fileName := range []string{IPADIC_VERB_FILE, IPADIC_ADJ_FILE} {
+		rows, err := builder.readIpadicCsv(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			key := conjugationKey{
+				originalForm:    row[ipadicColumnOriginalForm],
+				conjugationType: parseMecabConjugationType(row[ipadicColumnConjugationType]),
+				conjugationForm: parseMecabConjugationForm(row[ipadicColumnConjugationForm]),
+			}
+			if key.conjugationType == MecabConjugationTypeNone || key.conjugationForm == MecabConjugationFormNone {
+				t.Fatalf("unknown conjugation: %v", row)
+			}
+			expects[key] = append(expects[key], row[ipadicColumnWord])
+		}
+	}
+
+	for key, words := range expects {
+		actual, err := Conjugate(key.originalForm, key.conjugationType, key.conjugationForm)
+		if err != nil {
+			t.Errorf("Conjugate(%s, %s, %s) error = %v", key.originalForm, key.conjugationType, key.conjugationForm, err)
+			continue
+		}
+		if !containsWord(words, actual) {
+			t.Errorf("Conjugate(%s, %s, %s) = %v, expect one of %v", key.originalForm, key.conjugationType, key.conjugationForm, actual, words)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package zunda_mecab
 import (
 	"fmt"
 	"github.com/bluele/mecab-golang"
+	"regexp"
 	"strings"
 )
 
@@ -18,23 +19,48 @@ type MecabFeature struct {
 	ConjugationType MecabConjugationType // 活用型
 	ConjugationForm MecabConjugationForm // 活用形
 	OriginalForm    string               // 原形
-	// 読み
-	// 発音
+	Reading         string               // 読み
+	Pronunciation   string               // 発音
 }
 
 type MecabConditionFeature struct {
-	CheckWord            bool
-	Word                 string
-	CheckWordType        bool
-	WordType             MecabWordType
-	CheckWordSubType1    bool
-	WordSubType1         MecabWordSubType1
-	CheckOriginalForm    bool
-	OriginalForm         string
-	CheckConjugationForm bool
-	ConjugationForm      MecabConjugationForm
-	CheckConjugationType bool
-	ConjugationType      MecabConjugationType
+	CheckWord                bool
+	Word                     string
+	CheckWordPattern         bool
+	WordPattern              *regexp.Regexp
+	CheckWordSet             bool
+	WordSet                  []string
+	CheckWordList            bool
+	WordList                 string // 単語リスト名(WordListTable)
+	CheckWordType            bool
+	WordType                 MecabWordType
+	CheckWordSubType1        bool
+	WordSubType1             MecabWordSubType1
+	CheckWordSubType2        bool
+	WordSubType2             MecabWordSubType2
+	CheckWordSubType3        bool
+	WordSubType3             MecabWordSubType3
+	CheckOriginalForm        bool
+	OriginalForm             string
+	CheckOriginalFormPattern bool
+	OriginalFormPattern      *regexp.Regexp
+	CheckOriginalFormSet     bool
+	OriginalFormSet          []string
+	CheckOriginalFormList    bool
+	OriginalFormList         string // 単語リスト名(WordListTable)
+	CheckConjugationForm     bool
+	ConjugationForm          MecabConjugationForm
+	CheckConjugationType     bool
+	ConjugationType          MecabConjugationType
+	CheckReading             bool
+	Reading                  string
+	CheckPronunciation       bool
+	Pronunciation            string
+}
+
+// 単語リストの取得元
+type MecabWordListLoader interface {
+	SelectWordList(listName string) ([]string, error)
 }
 
 func (m *MecabConditionFeature) String() string {
@@ -42,12 +68,45 @@ func (m *MecabConditionFeature) String() string {
 	if m.CheckWord {
 		descriptions = append(descriptions, fmt.Sprintf("単語: %s", m.Word))
 	}
+	if m.CheckWordPattern && m.WordPattern != nil {
+		descriptions = append(descriptions, fmt.Sprintf("単語(正規表現): %s", m.WordPattern.String()))
+	}
+	if m.CheckWordSet {
+		descriptions = append(descriptions, fmt.Sprintf("単語(集合): [%s]", strings.Join(m.WordSet, "|")))
+	}
+	if m.CheckWordList {
+		descriptions = append(descriptions, fmt.Sprintf("単語(リスト): %s", m.WordList))
+	}
 	if m.CheckWordType {
 		descriptions = append(descriptions, fmt.Sprintf("品詞タイプ: %s", m.WordType.String()))
 	}
+	if m.CheckWordSubType1 {
+		descriptions = append(descriptions, fmt.Sprintf("品詞細分類1: %s", m.WordSubType1.String()))
+	}
+	if m.CheckWordSubType2 {
+		descriptions = append(descriptions, fmt.Sprintf("品詞細分類2: %s", m.WordSubType2.String()))
+	}
+	if m.CheckWordSubType3 {
+		descriptions = append(descriptions, fmt.Sprintf("品詞細分類3: %s", m.WordSubType3.String()))
+	}
 	if m.CheckOriginalForm {
 		descriptions = append(descriptions, fmt.Sprintf("基本形: %s", m.OriginalForm))
 	}
+	if m.CheckOriginalFormPattern && m.OriginalFormPattern != nil {
+		descriptions = append(descriptions, fmt.Sprintf("基本形(正規表現): %s", m.OriginalFormPattern.String()))
+	}
+	if m.CheckOriginalFormSet {
+		descriptions = append(descriptions, fmt.Sprintf("基本形(集合): [%s]", strings.Join(m.OriginalFormSet, "|")))
+	}
+	if m.CheckOriginalFormList {
+		descriptions = append(descriptions, fmt.Sprintf("基本形(リスト): %s", m.OriginalFormList))
+	}
+	if m.CheckReading {
+		descriptions = append(descriptions, fmt.Sprintf("読み: %s", m.Reading))
+	}
+	if m.CheckPronunciation {
+		descriptions = append(descriptions, fmt.Sprintf("発音: %s", m.Pronunciation))
+	}
 	return fmt.Sprintf("{%s}", strings.Join(descriptions, ","))
 }
 
@@ -115,7 +174,7 @@ func parseMecabFeatureNode(node *mecab.Node) MecabFeature {
 			EOS: eos,
 		}
 	}
-	return MecabFeature{
+	feature := MecabFeature{
 		EOS:             eos,
 		Word:            node.Surface(),
 		WordType:        parseMecabWordType(features[0]),
@@ -126,6 +185,12 @@ func parseMecabFeatureNode(node *mecab.Node) MecabFeature {
 		ConjugationForm: parseMecabConjugationForm(features[5]),
 		OriginalForm:    features[6],
 	}
+	// 未知語は読み・発音を持たない
+	if len(features) > 8 {
+		feature.Reading = features[7]
+		feature.Pronunciation = features[8]
+	}
+	return feature
 }
 
 /*
@@ -188,6 +253,11 @@ func parseMecabWordSubType2(keyword string) MecabWordSubType2 {
 		MecabWordSubType2None,
 		MecabWordSubType2Popular,
 		MecabWordSubType2NumberClassifier,
+		MecabWordSubType2Person,
+		MecabWordSubType2Area,
+		MecabWordSubType2Organization,
+		MecabWordSubType2Collocation,
+		MecabWordSubType2Quotation,
 	} {
 		if wordSubType.String() == keyword {
 			return wordSubType
@@ -200,6 +270,17 @@ func parseMecabWordSubType2(keyword string) MecabWordSubType2 {
 * 品詞細分類3のパース
  */
 func parseMecabWordSubType3(keyword string) MecabWordSubType3 {
+	for _, wordSubType := range []MecabWordSubType3{
+		MecabWordSubType3None,
+		MecabWordSubType3Popular,
+		MecabWordSubType3FamilyName,
+		MecabWordSubType3GivenName,
+		MecabWordSubType3Country,
+	} {
+		if wordSubType.String() == keyword {
+			return wordSubType
+		}
+	}
 	return MecabWordSubType3None
 }
 
@@ -213,6 +294,11 @@ func parseMecabConjugationType(keyword string) MecabConjugationType {
 		MecabConjugationTypeSpDa,
 		MecabConjugationTypeSpDesu,
 		MecabConjugationTypeSahenSuru,
+		MecabConjugationTypeSpNai,
+		MecabConjugationTypeSpTai,
+		MecabConjugationTypeSpMasu,
+		MecabConjugationTypeSpNu,
+		MecabConjugationTypeInvariant,
 		MecabConjugationTypeIchidan,
 		MecabConjugationTypeGodanNa,
 		MecabConjugationTypeGodanBa,
@@ -223,7 +309,20 @@ func parseMecabConjugationType(keyword string) MecabConjugationType {
 		MecabConjugationTypeGodanRaSp,
 		MecabConjugationTypeGodanKaIOnbin,
 		MecabConjugationTypeGodanGa,
+		MecabConjugationTypeIchidanKureru,
+		MecabConjugationTypeIchidanEru,
+		MecabConjugationTypeKahenKuruKanji,
+		MecabConjugationTypeKahenKuru,
+		MecabConjugationTypeSahenSuffixSuru,
+		MecabConjugationTypeSahenSuffixZuru,
+		MecabConjugationTypeGodanKaSokuOnbin,
+		MecabConjugationTypeGodanKaYuku,
+		MecabConjugationTypeGodanSa,
+		MecabConjugationTypeGodanRaAru,
+		MecabConjugationTypeGodanWaUOnbin,
 		MecabConjugationTypeI,
+		MecabConjugationTypeAuo,
+		MecabConjugationTypeIi,
 	} {
 		if conjugationType.String() == keyword {
 			return conjugationType
@@ -257,6 +356,14 @@ func parseMecabConjugationForm(keyword string) MecabConjugationForm {
 		MecabConjugationFormGendaiKihon,
 		MecabConjugationFormRenyou,
 		MecabConjugationFormRenyouTaSetsuzoku,
+		MecabConjugationFormRenyouDeSetsuzoku,
+		MecabConjugationFormRenyouNiSetsuzoku,
+		MecabConjugationFormKihonSokuOnbin,
+		MecabConjugationFormRenyouTeSetsuzoku,
+		MecabConjugationFormRenyouGozaiSetsuzoku,
+		MecabConjugationFormKateiShukuYaku2,
+		MecabConjugationFormGaruSetsuzoku,
+		MecabConjugationFormOnbinKihon,
 	} {
 		if conjugationForm.String() == keyword {
 			return conjugationForm
@@ -384,6 +491,11 @@ const (
 	MecabWordSubType2None             MecabWordSubType2 = iota // なし
 	MecabWordSubType2Popular                                   // 一般
 	MecabWordSubType2NumberClassifier                          // 助数詞
+	MecabWordSubType2Person                                    // 人名
+	MecabWordSubType2Area                                      // 地域
+	MecabWordSubType2Organization                              // 組織
+	MecabWordSubType2Collocation                               // 連語
+	MecabWordSubType2Quotation                                 // 引用
 )
 
 func (m MecabWordSubType2) String() string {
@@ -394,6 +506,16 @@ func (m MecabWordSubType2) String() string {
 		return "一般"
 	case MecabWordSubType2NumberClassifier:
 		return "助数詞"
+	case MecabWordSubType2Person:
+		return "人名"
+	case MecabWordSubType2Area:
+		return "地域"
+	case MecabWordSubType2Organization:
+		return "組織"
+	case MecabWordSubType2Collocation:
+		return "連語"
+	case MecabWordSubType2Quotation:
+		return "引用"
 	default:
 		return "未知"
 	}
@@ -404,11 +526,28 @@ type MecabWordSubType3 int
 
 const (
 	// 共通
-	MecabWordSubType3None MecabWordSubType3 = iota // なし
+	MecabWordSubType3None       MecabWordSubType3 = iota // なし
+	MecabWordSubType3Popular                             // 一般
+	MecabWordSubType3FamilyName                          // 姓
+	MecabWordSubType3GivenName                           // 名
+	MecabWordSubType3Country                             // 国
 )
 
 func (m MecabWordSubType3) String() string {
-	return "*"
+	switch m {
+	case MecabWordSubType3None:
+		return "*"
+	case MecabWordSubType3Popular:
+		return "一般"
+	case MecabWordSubType3FamilyName:
+		return "姓"
+	case MecabWordSubType3GivenName:
+		return "名"
+	case MecabWordSubType3Country:
+		return "国"
+	default:
+		return "未知"
+	}
 }
 
 // 活用型
@@ -423,6 +562,11 @@ const (
 	MecabConjugationTypeSpDa      // 特殊・ダ
 	MecabConjugationTypeSpDesu    // 特殊・デス
 	MecabConjugationTypeSahenSuru // サ変・スル
+	MecabConjugationTypeSpNai     // 特殊・ナイ
+	MecabConjugationTypeSpTai     // 特殊・タイ
+	MecabConjugationTypeSpMasu    // 特殊・マス
+	MecabConjugationTypeSpNu      // 特殊・ヌ
+	MecabConjugationTypeInvariant // 不変化型
 	// 助詞
 	// 動詞
 	MecabConjugationTypeIchidan          // 一段
@@ -435,9 +579,22 @@ const (
 	MecabConjugationTypeGodanRaSp        // 五段・ラ行特殊
 	MecabConjugationTypeGodanKaIOnbin    // 五段・カ行イ音便
 	MecabConjugationTypeGodanGa          // 五段・ガ行
+	MecabConjugationTypeIchidanKureru    // 一段・クレル
+	MecabConjugationTypeIchidanEru       // 一段・得ル
+	MecabConjugationTypeKahenKuruKanji   // カ変・来ル
+	MecabConjugationTypeKahenKuru        // カ変・クル
+	MecabConjugationTypeSahenSuffixSuru  // サ変・−スル
+	MecabConjugationTypeSahenSuffixZuru  // サ変・−ズル
+	MecabConjugationTypeGodanKaSokuOnbin // 五段・カ行促音便
+	MecabConjugationTypeGodanKaYuku      // 五段・カ行促音便ユク
+	MecabConjugationTypeGodanSa          // 五段・サ行
+	MecabConjugationTypeGodanRaAru       // 五段・ラ行アル
+	MecabConjugationTypeGodanWaUOnbin    // 五段・ワ行ウ音便
 	// 名詞
 	// 形容詞
-	MecabConjugationTypeI // 形容詞・イ段
+	MecabConjugationTypeI   // 形容詞・イ段
+	MecabConjugationTypeAuo // 形容詞・アウオ段
+	MecabConjugationTypeIi  // 形容詞・イイ
 	// 記号
 	// 連体詞
 )
@@ -454,6 +611,16 @@ func (m MecabConjugationType) String() string {
 		return "特殊・デス"
 	case MecabConjugationTypeSahenSuru:
 		return "サ変・スル"
+	case MecabConjugationTypeSpNai:
+		return "特殊・ナイ"
+	case MecabConjugationTypeSpTai:
+		return "特殊・タイ"
+	case MecabConjugationTypeSpMasu:
+		return "特殊・マス"
+	case MecabConjugationTypeSpNu:
+		return "特殊・ヌ"
+	case MecabConjugationTypeInvariant:
+		return "不変化型"
 	case MecabConjugationTypeIchidan:
 		return "一段"
 	case MecabConjugationTypeGodanNa:
@@ -474,8 +641,34 @@ func (m MecabConjugationType) String() string {
 		return "五段・カ行イ音便"
 	case MecabConjugationTypeGodanGa:
 		return "五段・ガ行"
+	case MecabConjugationTypeIchidanKureru:
+		return "一段・クレル"
+	case MecabConjugationTypeIchidanEru:
+		return "一段・得ル"
+	case MecabConjugationTypeKahenKuruKanji:
+		return "カ変・来ル"
+	case MecabConjugationTypeKahenKuru:
+		return "カ変・クル"
+	case MecabConjugationTypeSahenSuffixSuru:
+		return "サ変・−スル"
+	case MecabConjugationTypeSahenSuffixZuru:
+		return "サ変・−ズル"
+	case MecabConjugationTypeGodanKaSokuOnbin:
+		return "五段・カ行促音便"
+	case MecabConjugationTypeGodanKaYuku:
+		return "五段・カ行促音便ユク"
+	case MecabConjugationTypeGodanSa:
+		return "五段・サ行"
+	case MecabConjugationTypeGodanRaAru:
+		return "五段・ラ行アル"
+	case MecabConjugationTypeGodanWaUOnbin:
+		return "五段・ワ行ウ音便"
 	case MecabConjugationTypeI:
 		return "形容詞・イ段"
+	case MecabConjugationTypeAuo:
+		return "形容詞・アウオ段"
+	case MecabConjugationTypeIi:
+		return "形容詞・イイ"
 	default:
 		return "未知"
 	}
@@ -510,8 +703,16 @@ const (
 	MecabConjugationFormGendaiKihon         // 現代基本形
 	MecabConjugationFormRenyou              // 連用形
 	MecabConjugationFormRenyouTaSetsuzoku   // 連用タ接続
+	MecabConjugationFormRenyouDeSetsuzoku   // 連用デ接続
+	MecabConjugationFormRenyouNiSetsuzoku   // 連用ニ接続
+	MecabConjugationFormKihonSokuOnbin      // 基本形-促音便
 	// 名詞
 	// 形容詞
+	MecabConjugationFormRenyouTeSetsuzoku    // 連用テ接続
+	MecabConjugationFormRenyouGozaiSetsuzoku // 連用ゴザイ接続
+	MecabConjugationFormKateiShukuYaku2      // 仮定縮約２
+	MecabConjugationFormGaruSetsuzoku        // ガル接続
+	MecabConjugationFormOnbinKihon           // 音便基本形
 	// 記号
 	// 連体詞
 )
@@ -567,6 +768,22 @@ func (m MecabConjugationForm) String() string {
 		return "連用形"
 	case MecabConjugationFormRenyouTaSetsuzoku:
 		return "連用タ接続"
+	case MecabConjugationFormRenyouDeSetsuzoku:
+		return "連用デ接続"
+	case MecabConjugationFormRenyouNiSetsuzoku:
+		return "連用ニ接続"
+	case MecabConjugationFormKihonSokuOnbin:
+		return "基本形-促音便"
+	case MecabConjugationFormRenyouTeSetsuzoku:
+		return "連用テ接続"
+	case MecabConjugationFormRenyouGozaiSetsuzoku:
+		return "連用ゴザイ接続"
+	case MecabConjugationFormKateiShukuYaku2:
+		return "仮定縮約２"
+	case MecabConjugationFormGaruSetsuzoku:
+		return "ガル接続"
+	case MecabConjugationFormOnbinKihon:
+		return "音便基本形"
 	default:
 		return "未知"
 	}
@@ -0,0 +1,278 @@
+package zunda_mecab
+
+import (
+	"reflect"
+)
+
+/*
+* 複数の条件群(ルール)をまとめて照合するマッチャー
+* 各ルールの条件を状態に展開し、Featureを1回走査するだけで全ルールの合致位置を求める。
+* 合致の判定はGetMatchIndexと同じ(先頭から最短の開始位置、条件は貪欲に消費し後戻りしない)。
+ */
+type MecabMatcher struct {
+	states     []matcherState
+	ruleStarts []int
+	conditions []MecabCondition
+}
+
+type matcherState struct {
+	rule        int
+	accept      bool
+	condition   MecabCondition
+	conditionID int // 同一条件はルールをまたいで共有し、1つのFeatureにつき1回だけ評価する
+}
+
+/*
+* 条件群をマッチャーへコンパイルする
+* rulesの並び順が優先度(先頭が最優先)となる
+ */
+func CompileMecabMatcher(rules ...[]MecabCondition) *MecabMatcher {
+	matcher := &MecabMatcher{
+		states:     []matcherState{},
+		ruleStarts: make([]int, len(rules)),
+	}
+	for ruleIndex, conditions := range rules {
+		matcher.ruleStarts[ruleIndex] = len(matcher.states)
+		for _, condition := range conditions {
+			matcher.states = append(matcher.states, matcherState{
+				rule:        ruleIndex,
+				condition:   condition,
+				conditionID: matcher.conditionID(condition),
+			})
+		}
+		matcher.states = append(matcher.states, matcherState{
+			rule:   ruleIndex,
+			accept: true,
+		})
+	}
+	return matcher
+}
+
+func (m *MecabMatcher) conditionID(condition MecabCondition) int {
+	for id, registered := range m.conditions {
+		if reflect.DeepEqual(registered, condition) {
+			return id
+		}
+	}
+	m.conditions = append(m.conditions, condition)
+	return len(m.conditions) - 1
+}
+
+/*
+* ルール数
+ */
+func (m *MecabMatcher) Len() int {
+	return len(m.ruleStarts)
+}
+
+/*
+* 最優先で合致したルールを返す
+* return
+*   [0]: 合致したルールのインデックス
+*   [1]: 合致した先頭インデックス
+*   [2]: 合致
+ */
+func (w *MecabWrapper) MatchFirst(matcher *MecabMatcher, features []MecabFeature) (int, int, bool) {
+	for ruleIndex, index := range w.MatchAll(matcher, features) {
+		if index >= 0 {
+			return ruleIndex, index, true
+		}
+	}
+	return 0, 0, false
+}
+
+/*
+* 全ルールの合致位置を返す
+* return
+*   ルール毎の合致した先頭インデックス。合致しない場合は-1
+ */
+func (w *MecabWrapper) MatchAll(matcher *MecabMatcher, features []MecabFeature) []int {
+	results := make([]int, len(matcher.ruleStarts))
+	for i := range results {
+		results[i] = -1
+	}
+
+	// EOSのみの指定(GetMatchIndexと同様)
+	if len(features) <= 0 {
+		for ruleIndex, start := range matcher.ruleStarts {
+			state := matcher.states[start]
+			if !state.accept && state.condition.ConditionType == MecabConditionTypeEOS && matcher.states[start+1].accept {
+				results[ruleIndex] = 0
+			}
+		}
+		return results
+	}
+
+	// 状態毎の開始位置(同じ状態なら以降の遷移は同じなので最小の開始位置のみ保持する)
+	active := newMatcherThreads(len(matcher.states))
+	next := newMatcherThreads(len(matcher.states))
+	cache := newMatcherCache(len(matcher.conditions))
+	for position, feature := range features {
+		cache.clear()
+		for ruleIndex, start := range matcher.ruleStarts {
+			if results[ruleIndex] < 0 {
+				active.add(start, position)
+			}
+		}
+		next.clear()
+		for _, state := range active.states {
+			start := active.starts[state]
+			if results[matcher.states[state].rule] >= 0 && results[matcher.states[state].rule] <= start {
+				continue
+			}
+			w.stepMatcherState(matcher, state, start, &feature, cache, next, results)
+		}
+		active, next = next, active
+	}
+	// 終端処理
+	for _, state := range active.states {
+		w.stepMatcherState(matcher, state, active.starts[state], nil, nil, nil, results)
+	}
+	return results
+}
+
+/*
+* 1状態の遷移
+* featureがnilの場合は終端として扱う
+ */
+func (w *MecabWrapper) stepMatcherState(matcher *MecabMatcher, state int, start int, feature *MecabFeature, cache *matcherCache, next *matcherThreads, results []int) {
+	for {
+		current := matcher.states[state]
+		if current.accept {
+			if results[current.rule] < 0 || start < results[current.rule] {
+				results[current.rule] = start
+			}
+			return
+		}
+		if feature == nil {
+			switch current.condition.ConditionType {
+			case MecabConditionTypeNothingOrContinue, MecabConditionTypeEOS:
+				state++
+				continue
+			default:
+				return
+			}
+		}
+
+		match, evaluated := cache.get(current.conditionID)
+		if !evaluated {
+			match = w.matchFeatureWithCondition(*feature, current.condition)
+			cache.set(current.conditionID, match)
+		}
+		switch current.condition.ConditionType {
+		case MecabConditionTypeOne: // 1つに合致
+			if match {
+				w.acceptOrAdd(matcher, state+1, start, next, results)
+			}
+			return
+		case MecabConditionTypeOneOrNothing: // 0..1に合致
+			if match {
+				w.acceptOrAdd(matcher, state+1, start, next, results)
+				return
+			}
+			state++
+		case MecabConditionTypeNothingOrContinue: // 0..*に合致
+			if match {
+				next.add(state, start)
+				return
+			}
+			state++
+		case MecabConditionTypeEOS: // EOSに合致
+			if match {
+				next.add(state, start)
+			}
+			return
+		default:
+			// 条件不備
+			return
+		}
+	}
+}
+
+func (w *MecabWrapper) acceptOrAdd(matcher *MecabMatcher, state int, start int, next *matcherThreads, results []int) {
+	if matcher.states[state].accept {
+		w.stepMatcherState(matcher, state, start, nil, nil, nil, results)
+		return
+	}
+	next.add(state, start)
+}
+
+type matcherThreads struct {
+	starts []int
+	states []int
+}
+
+func newMatcherThreads(size int) *matcherThreads {
+	threads := &matcherThreads{
+		starts: make([]int, size),
+		states: make([]int, 0, size),
+	}
+	for i := range threads.starts {
+		threads.starts[i] = -1
+	}
+	return threads
+}
+
+func (t *matcherThreads) add(state int, start int) {
+	if t.starts[state] < 0 {
+		t.starts[state] = start
+		t.states = append(t.states, state)
+		return
+	}
+	if start < t.starts[state] {
+		t.starts[state] = start
+	}
+}
+
+func (t *matcherThreads) clear() {
+	for _, state := range t.states {
+		t.starts[state] = -1
+	}
+	t.states = t.states[:0]
+}
+
+// 1つのFeatureに対する条件毎の評価結果
+type matcherCache struct {
+	evaluated []bool
+	matched   []bool
+	ids       []int
+}
+
+func newMatcherCache(size int) *matcherCache {
+	return &matcherCache{
+		evaluated: make([]bool, size),
+		matched:   make([]bool, size),
+		ids:       make([]int, 0, size),
+	}
+}
+
+func (c *matcherCache) get(id int) (bool, bool) {
+	return c.matched[id], c.evaluated[id]
+}
+
+func (c *matcherCache) set(id int, matched bool) {
+	c.evaluated[id] = true
+	c.matched[id] = matched
+	c.ids = append(c.ids, id)
+}
+
+func (c *matcherCache) clear() {
+	for _, id := range c.ids {
+		c.evaluated[id] = false
+	}
+	c.ids = c.ids[:0]
+}
+
+/*
+* 先頭ルールの合致位置を返す(GetMatchIndexのコンパイル済み版)
+* return
+*   [0]: 合致
+*   [1]: 合致した先頭インデックス。合致しない場合は0
+ */
+func (w *MecabWrapper) GetCompiledMatchIndex(matcher *MecabMatcher, features []MecabFeature) (bool, int) {
+	results := w.MatchAll(matcher, features)
+	if len(results) <= 0 || results[0] < 0 {
+		return false, 0
+	}
+	return true, results[0]
+}
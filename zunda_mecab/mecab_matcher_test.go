@@ -0,0 +1,150 @@
+package zunda_mecab
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMecabMatcher(t *testing.T) {
+	noun := MecabConditionFeature{
+		CheckWordType: true,
+		WordType:      MecabWordTypeNoun,
+	}
+	symbol := MecabConditionFeature{
+		CheckWordType: true,
+		WordType:      MecabWordTypeSymbol,
+	}
+	eos := MecabConditionFeature{}
+	features := []MecabFeature{
+		{Word: "僕", WordType: MecabWordTypeNoun},
+		{Word: "は", WordType: MecabWordTypeParticle},
+		{Word: "人", WordType: MecabWordTypeNoun},
+		{Word: "。", WordType: MecabWordTypeSymbol},
+		{EOS: true},
+	}
+
+	tests := []struct {
+		name        string
+		rules       [][]MecabCondition
+		expectRule  int
+		expectIndex int
+		expectMatch bool
+	}{
+		{
+			name: "先頭ルールが優先",
+			rules: [][]MecabCondition{
+				{
+					{ConditionType: MecabConditionTypeOne, Features: []MecabConditionFeature{noun}},
+					{ConditionType: MecabConditionTypeNothingOrContinue, Features: []MecabConditionFeature{symbol}},
+					{ConditionType: MecabConditionTypeEOS, Features: []MecabConditionFeature{eos}},
+				},
+				{
+					{ConditionType: MecabConditionTypeOne, Features: []MecabConditionFeature{noun}},
+				},
+			},
+			expectRule:  0,
+			expectIndex: 2,
+			expectMatch: true,
+		},
+		{
+			name: "合致しないルールは飛ばす",
+			rules: [][]MecabCondition{
+				{
+					{ConditionType: MecabConditionTypeOne, Features: []MecabConditionFeature{symbol}},
+					{ConditionType: MecabConditionTypeOne, Features: []MecabConditionFeature{noun}},
+				},
+				{
+					{ConditionType: MecabConditionTypeOne, Features: []MecabConditionFeature{noun}},
+				},
+			},
+			expectRule:  1,
+			expectIndex: 0,
+			expectMatch: true,
+		},
+		{
+			name: "合致なし",
+			rules: [][]MecabCondition{
+				{
+					{ConditionType: MecabConditionTypeOne, Features: []MecabConditionFeature{symbol}},
+					{ConditionType: MecabConditionTypeOne, Features: []MecabConditionFeature{symbol}},
+				},
+			},
+			expectMatch: false,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			wrapper := MecabWrapper{
+				Logger: getTestLogger(),
+			}
+			matcher := CompileMecabMatcher(testCase.rules...)
+			actualRule, actualIndex, actualMatch := wrapper.MatchFirst(matcher, features)
+			if actualMatch != testCase.expectMatch {
+				t.Fatalf("MecabWrapper.MatchFirst() match = %v, expect %v", actualMatch, testCase.expectMatch)
+			}
+			if actualMatch && (actualRule != testCase.expectRule || actualIndex != testCase.expectIndex) {
+				t.Fatalf("MecabWrapper.MatchFirst() = (%v, %v) expect (%v, %v)", actualRule, actualIndex, testCase.expectRule, testCase.expectIndex)
+			}
+		})
+	}
+}
+
+/*
+* GetMatchIndexとの合致結果の比較
+ */
+func TestMecabMatcherCompatibility(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	wordTypes := []MecabWordType{
+		MecabWordTypeNoun,
+		MecabWordTypeParticle,
+		MecabWordTypeSymbol,
+	}
+	conditionTypes := []MecabConditionType{
+		MecabConditionTypeOne,
+		MecabConditionTypeOneOrNothing,
+		MecabConditionTypeNothingOrContinue,
+		MecabConditionTypeEOS,
+	}
+	randomFeatures := func() []MecabFeature {
+		features := []MecabFeature{}
+		for i := random.Intn(6); i > 0; i-- {
+			features = append(features, MecabFeature{WordType: wordTypes[random.Intn(len(wordTypes))]})
+		}
+		if random.Intn(2) == 0 {
+			features = append(features, MecabFeature{EOS: true})
+		}
+		return features
+	}
+	randomConditions := func() []MecabCondition {
+		conditions := []MecabCondition{}
+		for i := random.Intn(4); i > 0; i-- {
+			conditions = append(conditions, MecabCondition{
+				ConditionType: conditionTypes[random.Intn(len(conditionTypes))],
+				Features: []MecabConditionFeature{
+					{
+						CheckWordType: true,
+						WordType:      wordTypes[random.Intn(len(wordTypes))],
+					},
+				},
+			})
+		}
+		return conditions
+	}
+
+	wrapper := MecabWrapper{
+		Logger: getTestLogger(),
+	}
+	for i := 0; i < 2000; i++ {
+		features := randomFeatures()
+		rules := [][]MecabCondition{randomConditions(), randomConditions(), randomConditions()}
+		actual := wrapper.MatchAll(CompileMecabMatcher(rules...), features)
+		for ruleIndex, conditions := range rules {
+			expectMatch, expectIndex := wrapper.GetMatchIndex(features, conditions)
+			if expectMatch != (actual[ruleIndex] >= 0) || (expectMatch && expectIndex != actual[ruleIndex]) {
+				t.Fatalf("MecabWrapper.MatchAll()[%d] = %v, expect (%v, %v) features: %v, conditions: %v", ruleIndex, actual[ruleIndex], expectMatch, expectIndex, features, conditions)
+			}
+		}
+	}
+}
@@ -1,42 +1,98 @@
 package zunda_mecab
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/bluele/mecab-golang"
 	"go.uber.org/zap"
+	"regexp"
 	"strings"
+	"sync"
 )
 
+/*
+* MeCabを起動できない、解析できない
+* errors.Is(err, ErrTokenizerUnavailable)で判定する
+ */
+var ErrTokenizerUnavailable = errors.New("tokenizer unavailable")
+
+type TokenizerError struct {
+	Op  string
+	Err error
+}
+
+func (e *TokenizerError) Error() string {
+	return fmt.Sprintf("mecab %s: %v", e.Op, e.Err)
+}
+
+func (e *TokenizerError) Is(target error) bool {
+	return target == ErrTokenizerUnavailable
+}
+
+func (e *TokenizerError) Unwrap() error {
+	return e.Err
+}
+
 type MecabWrapper struct {
-	Logger *zap.Logger
+	Logger         *zap.Logger
+	WordListLoader MecabWordListLoader
+	Options        []string // MeCabの起動オプション(ex: "-d", "/usr/lib/mecab/dic/ipadic")。空の場合は"-Owakati"
+	PoolSize       int      // 解析後に再利用するMeCabの最大数。0の場合は解析ごとに起動、終了する
+
+	wordListsMutex sync.RWMutex
+	wordLists      map[string]wordList
+
+	poolOnce sync.Once
+	pool     chan *mecabTagger
+}
+
+/*
+* 起動済みのMeCab
+* 辞書の読み込みを伴うため、PoolSizeを指定した場合は使い回す
+ */
+type mecabTagger struct {
+	mecab  *mecab.MeCab
+	tagger *mecab.Tagger
+}
+
+func (t *mecabTagger) destroy() {
+	t.tagger.Destroy()
+	t.mecab.Destroy()
 }
 
 func (w *MecabWrapper) ParseToNode(text string) ([]MecabFeature, error) {
-	defer w.Logger.Sync()
-	sugar := w.Logger.Sugar()
+	return w.ParseToNodeContext(context.Background(), text)
+}
+
+/*
+* 形態素解析
+* 解析の前後でキャンセルを確認する(MeCabの解析自体は中断できない)
+ */
+func (w *MecabWrapper) ParseToNodeContext(ctx context.Context, text string) ([]MecabFeature, error) {
 
 	mecabFeatures := []MecabFeature{}
-	m, err := mecab.New("-Owakati")
-	if err != nil {
-		panic(err)
+	if err := ctx.Err(); err != nil {
+		return mecabFeatures, err
 	}
-	defer m.Destroy()
-
-	tg, err := m.NewTagger()
+	t, err := w.getTagger()
 	if err != nil {
 		return mecabFeatures, err
 	}
-	defer tg.Destroy()
+	defer w.putTagger(t)
 
-	lt, err := m.NewLattice(text)
+	lt, err := t.mecab.NewLattice(text)
 	if err != nil {
-		return mecabFeatures, err
+		return mecabFeatures, &TokenizerError{Op: "new lattice", Err: err}
 	}
 	defer lt.Destroy()
 
-	node := tg.ParseToNode(lt)
+	node := t.tagger.ParseToNode(lt)
 	for {
 		feature := parseMecabFeatureNode(node)
-		sugar.Debug(feature.String())
+		if ce := w.Logger.Check(zap.DebugLevel, "feature"); ce != nil {
+			ce.Write(zap.String("feature", feature.String()))
+		}
 		if len(mecabFeatures) != 0 || !feature.EOS {
 			mecabFeatures = append(mecabFeatures, feature)
 		}
@@ -44,10 +100,75 @@ func (w *MecabWrapper) ParseToNode(text string) ([]MecabFeature, error) {
 			break
 		}
 	}
+	if err := ctx.Err(); err != nil {
+		return mecabFeatures, err
+	}
 	return mecabFeatures, nil
 }
 
-func (w MecabWrapper) ParseToNodeWithoutEos(text string) ([]MecabFeature, error) {
+func (w *MecabWrapper) getTagger() (*mecabTagger, error) {
+	if w.PoolSize > 0 {
+		w.poolOnce.Do(func() {
+			w.pool = make(chan *mecabTagger, w.PoolSize)
+		})
+		select {
+		case t := <-w.pool:
+			return t, nil
+		default:
+		}
+	}
+	m, err := mecab.New(w.mecabOptions()...)
+	if err != nil {
+		return nil, &TokenizerError{Op: "new", Err: err}
+	}
+	tg, err := m.NewTagger()
+	if err != nil {
+		m.Destroy()
+		return nil, &TokenizerError{Op: "new tagger", Err: err}
+	}
+	return &mecabTagger{mecab: m, tagger: tg}, nil
+}
+
+/*
+* 解析後のMeCabを戻す
+* プールが一杯の場合は終了する
+ */
+func (w *MecabWrapper) putTagger(t *mecabTagger) {
+	if w.pool != nil {
+		select {
+		case w.pool <- t:
+			return
+		default:
+		}
+	}
+	t.destroy()
+}
+
+/*
+* 再利用のために保持しているMeCabの終了
+ */
+func (w *MecabWrapper) Close() {
+	if w.pool == nil {
+		return
+	}
+	for {
+		select {
+		case t := <-w.pool:
+			t.destroy()
+		default:
+			return
+		}
+	}
+}
+
+func (w *MecabWrapper) mecabOptions() []string {
+	if len(w.Options) == 0 {
+		return []string{"-Owakati"}
+	}
+	return w.Options
+}
+
+func (w *MecabWrapper) ParseToNodeWithoutEos(text string) ([]MecabFeature, error) {
 	features, err := w.ParseToNode(text)
 	if err != nil {
 		return features, err
@@ -63,7 +184,7 @@ func (w MecabWrapper) ParseToNodeWithoutEos(text string) ([]MecabFeature, error)
 	return eosRemovedFeatures, nil
 }
 
-func (w MecabWrapper) Construct(features []MecabFeature) string {
+func (w *MecabWrapper) Construct(features []MecabFeature) string {
 	words := []string{}
 	for _, feature := range features {
 		words = append(words, feature.Word)
@@ -78,10 +199,8 @@ func (w MecabWrapper) Construct(features []MecabFeature) string {
 *   [0]: 合致
 *   [0]: 合致した先頭インデックス。合致しない場合は-1
  */
-func (w MecabWrapper) GetMatchIndex(features []MecabFeature, conditions []MecabCondition) (bool, int) {
-	defer w.Logger.Sync()
-	sugar := w.Logger.Sugar()
-	sugar.Debugf("GetMatchIndex()")
+func (w *MecabWrapper) GetMatchIndex(features []MecabFeature, conditions []MecabCondition) (bool, int) {
+	w.Logger.Debug("GetMatchIndex()")
 
 	// EOSのみの指定
 	if len(features) <= 0 && len(conditions) == 1 && conditions[0].ConditionType == MecabConditionTypeEOS {
@@ -98,20 +217,18 @@ func (w MecabWrapper) GetMatchIndex(features []MecabFeature, conditions []MecabC
 	return false, 0
 }
 
-func (w MecabWrapper) getMatchIndex(features []MecabFeature, conditions []MecabCondition) bool {
-	defer w.Logger.Sync()
-	sugar := w.Logger.Sugar()
-	sugar.Debugf("getMatchIndex()")
+func (w *MecabWrapper) getMatchIndex(features []MecabFeature, conditions []MecabCondition) bool {
+	w.Logger.Debug("getMatchIndex()")
 	// 条件なしなら無条件に合致
 	if len(conditions) <= 0 {
-		sugar.Debugf("getMatchIndex() - nothing conditions")
+		w.Logger.Debug("getMatchIndex() - nothing conditions")
 		return true
 	}
 	match, length := w.matchFeaturesWithCondition(
 		features,
 		conditions[0])
 	if !match {
-		sugar.Debugf("getMatchIndex() - unmatch")
+		w.Logger.Debug("getMatchIndex() - unmatch")
 		return false
 	}
 	return w.getMatchIndex(features[length:], conditions[1:])
@@ -123,58 +240,64 @@ func (w MecabWrapper) getMatchIndex(features []MecabFeature, conditions []MecabC
 *   [0]: 合致
 *   [1]: 合致した要素数
  */
-func (w MecabWrapper) matchFeaturesWithCondition(features []MecabFeature, condition MecabCondition) (bool, int) {
-	defer w.Logger.Sync()
-	sugar := w.Logger.Sugar()
-	sugar.Debugf("matchFeaturesWithCondition() - condition type: %s", condition.ConditionType.String())
+func (w *MecabWrapper) matchFeaturesWithCondition(features []MecabFeature, condition MecabCondition) (bool, int) {
+	if ce := w.Logger.Check(zap.DebugLevel, "matchFeaturesWithCondition() - condition"); ce != nil {
+		ce.Write(zap.String("type", condition.ConditionType.String()))
+	}
 	switch condition.ConditionType {
 	case MecabConditionTypeOne: // 1つに合致
 		// 検証要素無し
 		if len(features) <= 0 {
-			sugar.Debug("matchFeaturesWithCondition() - feature not found")
+			w.Logger.Debug("matchFeaturesWithCondition() - feature not found")
 			return false, 0
 		}
 		if !w.matchFeatureWithCondition(features[0], condition) {
-			sugar.Debug("matchFeaturesWithCondition() - unmatch")
+			w.Logger.Debug("matchFeaturesWithCondition() - unmatch")
 			return false, 0
 		}
-		sugar.Debug("matchFeaturesWithCondition() - match")
+		w.Logger.Debug("matchFeaturesWithCondition() - match")
 		return true, 1
 
 	case MecabConditionTypeOneOrNothing: // 0..1に合致
 		// 検証要素無し
 		if len(features) <= 0 {
-			sugar.Debug("matchFeaturesWithCondition() - feature not found")
+			w.Logger.Debug("matchFeaturesWithCondition() - feature not found")
 			return false, 0
 		}
 		// 0以上なので常にマッチ
 		if w.matchFeatureWithCondition(features[0], condition) {
-			sugar.Debug("matchFeaturesWithCondition() - match.length: 1")
+			w.Logger.Debug("matchFeaturesWithCondition() - match.length: 1")
 			return true, 1
 		}
-		sugar.Debug("matchFeaturesWithCondition() - match.length: 0")
+		w.Logger.Debug("matchFeaturesWithCondition() - match.length: 0")
 		return true, 0
 	case MecabConditionTypeNothingOrContinue: // 0..*に合致
 		// 0以上なので常にマッチ
 		for i, feature := range features {
 			if !w.matchFeatureWithCondition(feature, condition) {
-				sugar.Debugf("matchFeaturesWithCondition() - match.length: %d", i)
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeaturesWithCondition() - match"); ce != nil {
+					ce.Write(zap.Int("length", i))
+				}
 				return true, i
 			}
 		}
 
-		sugar.Debugf("matchFeaturesWithCondition() - retain all match.length: %d", len(features))
+		if ce := w.Logger.Check(zap.DebugLevel, "matchFeaturesWithCondition() - retain all match"); ce != nil {
+			ce.Write(zap.Int("length", len(features)))
+		}
 		return true, len(features)
 
 	case MecabConditionTypeEOS: // EOSに合致
 		for _, feature := range features {
 			if !w.matchFeatureWithCondition(feature, condition) {
 
-				sugar.Debug("matchFeaturesWithCondition() - unmatch")
+				w.Logger.Debug("matchFeaturesWithCondition() - unmatch")
 				return false, 0
 			}
 		}
-		sugar.Debugf("matchFeaturesWithCondition() - match.length: %d", len(features))
+		if ce := w.Logger.Check(zap.DebugLevel, "matchFeaturesWithCondition() - match"); ce != nil {
+			ce.Write(zap.Int("length", len(features)))
+		}
 		return true, len(features)
 	default:
 		// 条件不備
@@ -182,42 +305,116 @@ func (w MecabWrapper) matchFeaturesWithCondition(features []MecabFeature, condit
 	}
 }
 
-func (w MecabWrapper) matchFeatureWithCondition(feature MecabFeature, condition MecabCondition) bool {
-	defer w.Logger.Sync()
-	sugar := w.Logger.Sugar()
-	sugar.Debugf("matchFeatureWithCondition() - Feature: %s, condition type: %s", feature.String(), condition.ConditionType.String())
+func (w *MecabWrapper) matchFeatureWithCondition(feature MecabFeature, condition MecabCondition) bool {
+	if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition()"); ce != nil {
+		ce.Write(zap.String("Feature", feature.String()), zap.String("type", condition.ConditionType.String()))
+	}
 	switch condition.ConditionType {
 	case MecabConditionTypeOne, MecabConditionTypeOneOrNothing, MecabConditionTypeNothingOrContinue: // 1つに合致, 0..1に合致, 0..*に合致
 		for _, conditionFeature := range condition.Features {
-			sugar.Debugf("matchFeatureWithCondition() - condition feature: %s", conditionFeature.String())
+			if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - condition"); ce != nil {
+				ce.Write(zap.String("feature", conditionFeature.String()))
+			}
 			if conditionFeature.CheckWord && feature.Word != conditionFeature.Word {
-				sugar.Debugf("matchFeatureWithCondition() - unmatch word.feature: %s, condition: %s", feature.Word, conditionFeature.Word)
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch word"); ce != nil {
+					ce.Write(zap.String("feature", feature.Word), zap.String("condition", conditionFeature.Word))
+				}
+				continue
+			}
+			if conditionFeature.CheckWordPattern && !matchPattern(conditionFeature.WordPattern, feature.Word) {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch word pattern"); ce != nil {
+					ce.Write(zap.String("feature", feature.Word))
+				}
+				continue
+			}
+			if conditionFeature.CheckWordSet && !containsWord(conditionFeature.WordSet, feature.Word) {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch word set"); ce != nil {
+					ce.Write(zap.String("feature", feature.Word))
+				}
+				continue
+			}
+			if conditionFeature.CheckWordList && !w.containsWordList(conditionFeature.WordList, feature.Word) {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch word list"); ce != nil {
+					ce.Write(zap.String("feature", feature.Word), zap.String("list", conditionFeature.WordList))
+				}
 				continue
 			}
 			if conditionFeature.CheckWordType && feature.WordType != conditionFeature.WordType {
-				sugar.Debugf("matchFeatureWithCondition() - unmatch word type.feature: %s, condition: %s", feature.WordType.String(), conditionFeature.WordType.String())
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch word type"); ce != nil {
+					ce.Write(zap.String("feature", feature.WordType.String()), zap.String("condition", conditionFeature.WordType.String()))
+				}
 				continue
 			}
 			if conditionFeature.CheckWordSubType1 && feature.WordSubType1 != conditionFeature.WordSubType1 {
-				sugar.Debugf("matchFeatureWithCondition() - unmatch word sub type1.feature: %s, condition: %s", feature.WordSubType1.String(), conditionFeature.WordSubType1.String())
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch word sub type1"); ce != nil {
+					ce.Write(zap.String("feature", feature.WordSubType1.String()), zap.String("condition", conditionFeature.WordSubType1.String()))
+				}
+				continue
+			}
+			if conditionFeature.CheckWordSubType2 && feature.WordSubType2 != conditionFeature.WordSubType2 {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch word sub type2"); ce != nil {
+					ce.Write(zap.String("feature", feature.WordSubType2.String()), zap.String("condition", conditionFeature.WordSubType2.String()))
+				}
+				continue
+			}
+			if conditionFeature.CheckWordSubType3 && feature.WordSubType3 != conditionFeature.WordSubType3 {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch word sub type3"); ce != nil {
+					ce.Write(zap.String("feature", feature.WordSubType3.String()), zap.String("condition", conditionFeature.WordSubType3.String()))
+				}
 				continue
 			}
 			if conditionFeature.CheckOriginalForm && feature.OriginalForm != conditionFeature.OriginalForm {
-				sugar.Debugf("matchFeatureWithCondition() - unmatch original form.feature: %s, condition: %s", feature.OriginalForm, conditionFeature.OriginalForm)
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch original form"); ce != nil {
+					ce.Write(zap.String("feature", feature.OriginalForm), zap.String("condition", conditionFeature.OriginalForm))
+				}
+				continue
+			}
+			if conditionFeature.CheckOriginalFormPattern && !matchPattern(conditionFeature.OriginalFormPattern, feature.OriginalForm) {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch original form pattern"); ce != nil {
+					ce.Write(zap.String("feature", feature.OriginalForm))
+				}
+				continue
+			}
+			if conditionFeature.CheckOriginalFormSet && !containsWord(conditionFeature.OriginalFormSet, feature.OriginalForm) {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch original form set"); ce != nil {
+					ce.Write(zap.String("feature", feature.OriginalForm))
+				}
+				continue
+			}
+			if conditionFeature.CheckOriginalFormList && !w.containsWordList(conditionFeature.OriginalFormList, feature.OriginalForm) {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch original form list"); ce != nil {
+					ce.Write(zap.String("feature", feature.OriginalForm), zap.String("list", conditionFeature.OriginalFormList))
+				}
 				continue
 			}
 			if conditionFeature.CheckConjugationType && feature.ConjugationType != conditionFeature.ConjugationType {
-				sugar.Debugf("matchFeatureWithCondition() - unmatch conjugation type.feature: %s, condition: %s", feature.ConjugationType, conditionFeature.ConjugationType)
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch conjugation type"); ce != nil {
+					ce.Write(zap.Stringer("feature", feature.ConjugationType), zap.Stringer("condition", conditionFeature.ConjugationType))
+				}
 				continue
 			}
 			if conditionFeature.CheckConjugationForm && feature.ConjugationForm != conditionFeature.ConjugationForm {
-				sugar.Debugf("matchFeatureWithCondition() - unmatch conjugation form.feature: %s, condition: %s", feature.ConjugationForm, conditionFeature.ConjugationForm)
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch conjugation form"); ce != nil {
+					ce.Write(zap.Stringer("feature", feature.ConjugationForm), zap.Stringer("condition", conditionFeature.ConjugationForm))
+				}
 				continue
 			}
-			sugar.Debug("matchFeatureWithCondition() - match")
+			if conditionFeature.CheckReading && feature.Reading != conditionFeature.Reading {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch reading"); ce != nil {
+					ce.Write(zap.String("feature", feature.Reading), zap.String("condition", conditionFeature.Reading))
+				}
+				continue
+			}
+			if conditionFeature.CheckPronunciation && feature.Pronunciation != conditionFeature.Pronunciation {
+				if ce := w.Logger.Check(zap.DebugLevel, "matchFeatureWithCondition() - unmatch pronunciation"); ce != nil {
+					ce.Write(zap.String("feature", feature.Pronunciation), zap.String("condition", conditionFeature.Pronunciation))
+				}
+				continue
+			}
+			w.Logger.Debug("matchFeatureWithCondition() - match")
 			return true
 		}
-		sugar.Debug("matchFeatureWithCondition() - unmatch")
+		w.Logger.Debug("matchFeatureWithCondition() - unmatch")
 		return false
 
 	case MecabConditionTypeEOS: // EOSに合致
@@ -227,3 +424,76 @@ func (w MecabWrapper) matchFeatureWithCondition(feature MecabFeature, condition
 	}
 	return false
 }
+
+func matchPattern(pattern *regexp.Regexp, word string) bool {
+	if pattern == nil {
+		return false
+	}
+	return pattern.MatchString(word)
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+* 単語リストに含まれるか
+* 単語リストは初回参照時にWordListLoaderから読み込む
+* 読み込めなかった場合は一度だけログに出力し、以降は含まれないものとする
+ */
+func (w *MecabWrapper) containsWordList(listName string, word string) bool {
+	_, ok := w.getWordList(listName)[word]
+	return ok
+}
+
+/*
+* 読み込んだ単語リスト(読み込めなかった場合はerr)
+ */
+type wordList struct {
+	words map[string]struct{}
+	err   error
+}
+
+func (w *MecabWrapper) getWordList(listName string) map[string]struct{} {
+	w.wordListsMutex.RLock()
+	list, ok := w.wordLists[listName]
+	w.wordListsMutex.RUnlock()
+	if ok {
+		return list.words
+	}
+
+	w.wordListsMutex.Lock()
+	defer w.wordListsMutex.Unlock()
+	if list, ok := w.wordLists[listName]; ok {
+		return list.words
+	}
+	list = w.loadWordList(listName)
+	if list.err != nil {
+		w.Logger.Error("getWordList() - can not load word list", zap.String("list", listName), zap.Error(list.err))
+	}
+	if w.wordLists == nil {
+		w.wordLists = map[string]wordList{}
+	}
+	w.wordLists[listName] = list
+	return list.words
+}
+
+func (w *MecabWrapper) loadWordList(listName string) wordList {
+	if w.WordListLoader == nil {
+		return wordList{err: fmt.Errorf("word list loader is not set")}
+	}
+	rows, err := w.WordListLoader.SelectWordList(listName)
+	if err != nil {
+		return wordList{err: err}
+	}
+	words := make(map[string]struct{}, len(rows))
+	for _, row := range rows {
+		words[row] = struct{}{}
+	}
+	return wordList{words: words}
+}
@@ -1,8 +1,10 @@
 package zunda_mecab
 
 import (
+	"errors"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"regexp"
 	"testing"
 )
 
@@ -1238,3 +1240,262 @@ func TestGetMatchIndex(t *testing.T) {
 		})
 	}
 }
+
+type testWordListLoader struct{}
+
+func (t testWordListLoader) SelectWordList(listName string) ([]string, error) {
+	switch listName {
+	case "敬語":
+		return []string{"です", "ます"}, nil
+	default:
+		return []string{}, nil
+	}
+}
+
+func TestGetMatchIndexExtendedCondition(t *testing.T) {
+	features := []MecabFeature{
+		{
+			Word:         "ずんだ",
+			WordType:     MecabWordTypeNoun,
+			WordSubType1: MecabWordSubType1NounPopuler,
+			WordSubType2: MecabWordSubType2Person,
+			WordSubType3: MecabWordSubType3GivenName,
+			OriginalForm: "ずんだ",
+			Reading:      "ズンダ",
+		},
+		{
+			Word:            "でし",
+			WordType:        MecabWordTypeAuxiliaryVerb,
+			ConjugationType: MecabConjugationTypeSpDesu,
+			ConjugationForm: MecabConjugationFormRenyou,
+			OriginalForm:    "です",
+			Reading:         "デシ",
+			Pronunciation:   "デシ",
+		},
+		{
+			EOS: true,
+		},
+	}
+	tests := []struct {
+		name        string
+		conditions  []MecabCondition
+		expectMatch bool
+		expectIndex int
+	}{
+		{
+			name: "単語(正規表現)",
+			conditions: []MecabCondition{
+				{
+					ConditionType: MecabConditionTypeOne,
+					Features: []MecabConditionFeature{
+						{
+							CheckWordPattern: true,
+							WordPattern:      regexp.MustCompile("^で[しす]$"),
+						},
+					},
+				},
+			},
+			expectMatch: true,
+			expectIndex: 1,
+		},
+		{
+			name: "基本形(集合)",
+			conditions: []MecabCondition{
+				{
+					ConditionType: MecabConditionTypeOne,
+					Features: []MecabConditionFeature{
+						{
+							CheckOriginalFormSet: true,
+							OriginalFormSet:      []string{"ます", "です"},
+						},
+					},
+				},
+			},
+			expectMatch: true,
+			expectIndex: 1,
+		},
+		{
+			name: "基本形(リスト)",
+			conditions: []MecabCondition{
+				{
+					ConditionType: MecabConditionTypeOne,
+					Features: []MecabConditionFeature{
+						{
+							CheckOriginalFormList: true,
+							OriginalFormList:      "敬語",
+						},
+					},
+				},
+			},
+			expectMatch: true,
+			expectIndex: 1,
+		},
+		{
+			name: "Unmatch-単語(リスト)",
+			conditions: []MecabCondition{
+				{
+					ConditionType: MecabConditionTypeOne,
+					Features: []MecabConditionFeature{
+						{
+							CheckWordList: true,
+							WordList:      "敬語",
+						},
+					},
+				},
+			},
+			expectMatch: false,
+			expectIndex: 0,
+		},
+		{
+			name: "品詞細分類2,3+読み",
+			conditions: []MecabCondition{
+				{
+					ConditionType: MecabConditionTypeOne,
+					Features: []MecabConditionFeature{
+						{
+							CheckWordSubType2: true,
+							WordSubType2:      MecabWordSubType2Person,
+							CheckWordSubType3: true,
+							WordSubType3:      MecabWordSubType3GivenName,
+							CheckReading:      true,
+							Reading:           "ズンダ",
+						},
+					},
+				},
+				{
+					ConditionType: MecabConditionTypeOne,
+					Features: []MecabConditionFeature{
+						{
+							CheckPronunciation: true,
+							Pronunciation:      "デシ",
+						},
+					},
+				},
+			},
+			expectMatch: true,
+			expectIndex: 0,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			wrapper := MecabWrapper{
+				Logger:         getTestLogger(),
+				WordListLoader: testWordListLoader{},
+			}
+			actualMatch, actualIndex := wrapper.GetMatchIndex(features, testCase.conditions)
+			if actualMatch != testCase.expectMatch || actualIndex != testCase.expectIndex {
+				t.Fatalf("MecabWrapper.GetMatchIndex() = (%v, %v) expect (%v, %v)", actualMatch, actualIndex, testCase.expectMatch, testCase.expectIndex)
+			}
+		})
+	}
+}
+
+/*
+* 読み込めない単語リスト(読み込んだ回数を数える)
+ */
+type failingWordListLoader struct {
+	calls int
+}
+
+func (t *failingWordListLoader) SelectWordList(listName string) ([]string, error) {
+	t.calls++
+	return nil, errors.New("no such table: WordListTable")
+}
+
+func TestGetMatchIndexWordListLoadError(t *testing.T) {
+	features := []MecabFeature{
+		{Word: "ずんだ", WordType: MecabWordTypeNoun},
+		{Word: "です", WordType: MecabWordTypeAuxiliaryVerb, OriginalForm: "です"},
+	}
+	conditions := []MecabCondition{
+		{
+			ConditionType: MecabConditionTypeOne,
+			Features: []MecabConditionFeature{
+				{
+					CheckWordList: true,
+					WordList:      "敬語",
+				},
+			},
+		},
+	}
+	loader := &failingWordListLoader{}
+	wrapper := MecabWrapper{
+		Logger:         getTestLogger(),
+		WordListLoader: loader,
+	}
+	for i := 0; i < 3; i++ {
+		if match, _ := wrapper.GetMatchIndex(features, conditions); match {
+			t.Fatalf("MecabWrapper.GetMatchIndex() match = true, expect false")
+		}
+	}
+	if loader.calls != 1 {
+		t.Fatalf("SelectWordList() calls = %v, expect 1", loader.calls)
+	}
+}
+
+/*
+* data/config.yamlと同じ"fatal"レベルのロガー
+ */
+func getBenchmarkLogger() *zap.Logger {
+	logger := getTestLogger()
+	return logger.WithOptions(zap.IncreaseLevel(zapcore.FatalLevel))
+}
+
+func BenchmarkGetMatchIndex(b *testing.B) {
+	features := []MecabFeature{
+		{Word: "僕", WordType: MecabWordTypeNoun, WordSubType1: MecabWordSubType1NounPronoun, OriginalForm: "僕"},
+		{Word: "は", WordType: MecabWordTypeParticle, WordSubType1: MecabWordSubType1ParticleCombination, OriginalForm: "は"},
+		{Word: "腹筋", WordType: MecabWordTypeNoun, WordSubType1: MecabWordSubType1NounPopuler, OriginalForm: "腹筋"},
+		{Word: "し", WordType: MecabWordTypeVerb, ConjugationType: MecabConjugationTypeSahenSuru, ConjugationForm: MecabConjugationFormRenyou, OriginalForm: "する"},
+		{Word: "ます", WordType: MecabWordTypeAuxiliaryVerb, ConjugationType: MecabConjugationTypeSpDesu, ConjugationForm: MecabConjugationFormKihon, OriginalForm: "ます"},
+		{Word: "。", WordType: MecabWordTypeSymbol, WordSubType1: MecabWordSubType1SymbolPeriod, OriginalForm: "。"},
+		{EOS: true},
+	}
+	conditions := []MecabCondition{
+		{
+			ConditionType: MecabConditionTypeOne,
+			Features: []MecabConditionFeature{
+				{
+					CheckWordType: true,
+					WordType:      MecabWordTypeVerb,
+				},
+			},
+		},
+		{
+			ConditionType: MecabConditionTypeOne,
+			Features: []MecabConditionFeature{
+				{
+					CheckWordSet:  true,
+					WordSet:       []string{"です", "ます"},
+					CheckWordType: true,
+					WordType:      MecabWordTypeAuxiliaryVerb,
+				},
+			},
+		},
+		{
+			ConditionType: MecabConditionTypeNothingOrContinue,
+			Features: []MecabConditionFeature{
+				{
+					CheckWordType: true,
+					WordType:      MecabWordTypeSymbol,
+				},
+			},
+		},
+		{
+			ConditionType: MecabConditionTypeEOS,
+			Features: []MecabConditionFeature{
+				{},
+			},
+		},
+	}
+	wrapper := MecabWrapper{
+		Logger: getBenchmarkLogger(),
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wrapper.GetMatchIndex(features, conditions)
+	}
+}
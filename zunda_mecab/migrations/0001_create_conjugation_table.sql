@@ -0,0 +1,22 @@
+-- 動詞・形容詞の活用テーブルと辞書の出典
+CREATE TABLE IF NOT EXISTS ConjugationTable(
+  base_word TEXT,
+  word_type TEXT,
+  conjugation_type TEXT,
+  conjugation_form TEXT,
+  word TEXT,
+  UNIQUE(base_word, conjugation_type, conjugation_form, word)
+);
+CREATE INDEX IF NOT EXISTS ConjugationTableBaseWord ON ConjugationTable(base_word, conjugation_form);
+CREATE TABLE IF NOT EXISTS MetadataTable(
+  key TEXT PRIMARY KEY,
+  value TEXT
+);
+-- 旧setup.shの動詞の未然形変換テーブルは活用型を問わない行として引き継ぐ
+CREATE TABLE IF NOT EXISTS ConvertVerbConjugationTable(
+  base_word TEXT,
+  mizen TEXT
+);
+INSERT OR IGNORE INTO ConjugationTable(base_word, word_type, conjugation_type, conjugation_form, word)
+  SELECT base_word, '動詞', '*', '未然形', mizen FROM ConvertVerbConjugationTable;
+DROP TABLE ConvertVerbConjugationTable;
@@ -0,0 +1,6 @@
+-- 条件用の単語リストテーブル
+CREATE TABLE IF NOT EXISTS WordListTable(
+  list_name TEXT,
+  word TEXT
+);
+CREATE INDEX IF NOT EXISTS WordListTableListName ON WordListTable(list_name);
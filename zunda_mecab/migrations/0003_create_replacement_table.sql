@@ -0,0 +1,8 @@
+-- 利用者の置換辞書
+CREATE TABLE IF NOT EXISTS ReplacementTable(
+  source TEXT,
+  source_key TEXT,
+  word_type TEXT,
+  target TEXT,
+  UNIQUE(source, source_key, word_type)
+);
@@ -0,0 +1,133 @@
+package zunda_mecab
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+* 置換辞書の照合対象
+ */
+type ReplacementKey int
+
+const (
+	ReplacementKeyWord         ReplacementKey = iota // 表層形
+	ReplacementKeyOriginalForm                       // 原形(活用した語もまとめて置換する)
+)
+
+func (r ReplacementKey) String() string {
+	switch r {
+	case ReplacementKeyWord:
+		return "word"
+	case ReplacementKeyOriginalForm:
+		return "original_form"
+	default:
+		return "unknown"
+	}
+}
+
+func ParseReplacementKey(keyword string) (ReplacementKey, error) {
+	for _, key := range []ReplacementKey{ReplacementKeyWord, ReplacementKeyOriginalForm} {
+		if key.String() == keyword {
+			return key, nil
+		}
+	}
+	return ReplacementKeyWord, fmt.Errorf("unknown replacement key: %s", keyword)
+}
+
+/*
+* 置換辞書の品詞
+* 空または"*"の場合は品詞を問わない(MecabWordTypeUnknown)
+ */
+func ParseReplacementWordType(keyword string) (MecabWordType, error) {
+	if keyword == "" || keyword == "*" {
+		return MecabWordTypeUnknown, nil
+	}
+	wordType := parseMecabWordType(keyword)
+	if wordType == MecabWordTypeUnknown {
+		return wordType, fmt.Errorf("unknown word type: %s", keyword)
+	}
+	return wordType, nil
+}
+
+func replacementWordTypeString(wordType MecabWordType) string {
+	if wordType == MecabWordTypeUnknown {
+		return "*"
+	}
+	return wordType.String()
+}
+
+/*
+* 置換辞書の1行
+* ex) {Source: "ですが", Key: ReplacementKeyWord, WordType: MecabWordTypeConjunction, Target: "だけど"}
+ */
+type ReplacementRow struct {
+	Source   string
+	Key      ReplacementKey
+	WordType MecabWordType // MecabWordTypeUnknownの場合は品詞を問わない
+	Target   string
+}
+
+/*
+* Featureが置換対象か
+ */
+func (r ReplacementRow) Match(feature MecabFeature) bool {
+	if feature.EOS {
+		return false
+	}
+	if r.WordType != MecabWordTypeUnknown && r.WordType != feature.WordType {
+		return false
+	}
+	switch r.Key {
+	case ReplacementKeyOriginalForm:
+		return feature.OriginalForm == r.Source
+	default:
+		return feature.Word == r.Source
+	}
+}
+
+/*
+* 置換辞書CSVの読み込み
+* 列: 置換元,置換先[,品詞[,照合対象(word|original_form)]]
+* 空行と#で始まる行は読み飛ばす
+ */
+func ReadReplacementCsv(reader io.Reader) ([]ReplacementRow, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.Comment = '#'
+	rows := []ReplacementRow{}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := csvReader.FieldPos(0)
+		if len(record) < 2 || len(record) > 4 {
+			return nil, fmt.Errorf("line %d: expect 2 to 4 columns, got %d", line, len(record))
+		}
+		row := ReplacementRow{
+			Source: strings.TrimSpace(record[0]),
+			Target: strings.TrimSpace(record[1]),
+		}
+		if row.Source == "" {
+			return nil, fmt.Errorf("line %d: empty source", line)
+		}
+		if len(record) > 2 {
+			if row.WordType, err = ParseReplacementWordType(strings.TrimSpace(record[2])); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		if len(record) > 3 && strings.TrimSpace(record[3]) != "" {
+			if row.Key, err = ParseReplacementKey(strings.TrimSpace(record[3])); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
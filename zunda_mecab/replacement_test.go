@@ -0,0 +1,94 @@
+package zunda_mecab
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadReplacementCsv(t *testing.T) {
+	tests := []struct {
+		name        string
+		csv         string
+		expect      []ReplacementRow
+		expectError bool
+	}{
+		{
+			name: "全列指定と省略",
+			csv: strings.Join([]string{
+				"# 置換元,置換先,品詞,照合対象",
+				"ずんだ餅,ずんだもち",
+				"僕,ボク,名詞",
+				"言う,言うのだ,動詞,original_form",
+				"",
+			}, "\n"),
+			expect: []ReplacementRow{
+				{Source: "ずんだ餅", Key: ReplacementKeyWord, WordType: MecabWordTypeUnknown, Target: "ずんだもち"},
+				{Source: "僕", Key: ReplacementKeyWord, WordType: MecabWordTypeNoun, Target: "ボク"},
+				{Source: "言う", Key: ReplacementKeyOriginalForm, WordType: MecabWordTypeVerb, Target: "言うのだ"},
+			},
+		},
+		{
+			name:        "未知の品詞",
+			csv:         "僕,ボク,代名詞\n",
+			expectError: true,
+		},
+		{
+			name:        "列不足",
+			csv:         "僕\n",
+			expectError: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := ReadReplacementCsv(strings.NewReader(testCase.csv))
+			if (err != nil) != testCase.expectError {
+				t.Fatalf("ReadReplacementCsv() error = %v, expectError %v", err, testCase.expectError)
+			}
+			if !testCase.expectError && !reflect.DeepEqual(actual, testCase.expect) {
+				t.Fatalf("ReadReplacementCsv() = %v, expect %v", actual, testCase.expect)
+			}
+		})
+	}
+}
+
+func TestReplacementRowMatch(t *testing.T) {
+	feature := MecabFeature{Word: "言っ", WordType: MecabWordTypeVerb, OriginalForm: "言う"}
+	tests := []struct {
+		name        string
+		replacement ReplacementRow
+		expect      bool
+	}{
+		{
+			name:        "表層形",
+			replacement: ReplacementRow{Source: "言っ", Key: ReplacementKeyWord},
+			expect:      true,
+		},
+		{
+			name:        "原形",
+			replacement: ReplacementRow{Source: "言う", Key: ReplacementKeyOriginalForm, WordType: MecabWordTypeVerb},
+			expect:      true,
+		},
+		{
+			name:        "品詞違い",
+			replacement: ReplacementRow{Source: "言っ", Key: ReplacementKeyWord, WordType: MecabWordTypeNoun},
+			expect:      false,
+		},
+		{
+			name:        "原形を表層形で照合",
+			replacement: ReplacementRow{Source: "言う", Key: ReplacementKeyWord},
+			expect:      false,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			if actual := testCase.replacement.Match(feature); actual != testCase.expect {
+				t.Fatalf("ReplacementRow.Match() = %v, expect %v", actual, testCase.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package zunda_mecab
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+/*
+* IPADIC CSVの文字コード
+ */
+type IpadicEncoding string
+
+const (
+	IpadicEncodingAuto  IpadicEncoding = "auto" // UTF-8として妥当でなければEUC-JPとして扱う
+	IpadicEncodingEucJp IpadicEncoding = "euc-jp"
+	IpadicEncodingUtf8  IpadicEncoding = "utf-8"
+)
+
+const (
+	IPADIC_VERB_FILE = "Verb.csv"
+	IPADIC_ADJ_FILE  = "Adj.csv"
+)
+
+// IPADIC CSVの列
+const (
+	ipadicColumnWord            = 0
+	ipadicColumnWordType        = 4
+	ipadicColumnConjugationType = 8
+	ipadicColumnConjugationForm = 9
+	ipadicColumnOriginalForm    = 10
+	ipadicColumnCount           = 13
+)
+
+/*
+* ローカルのIPADICからzunda.dbを作成する
+ */
+type ZundaDbBuilder struct {
+	Logger    *zap.Logger // nilの場合はログを出力しない
+	IpadicDir string
+	Encoding  IpadicEncoding // 空の場合はIpadicEncodingAuto
+	Version   string         // 空の場合はconfigure.in、ディレクトリ名から推定する
+}
+
+func (b *ZundaDbBuilder) getLogger() *zap.Logger {
+	if b.Logger == nil {
+		return zap.NewNop()
+	}
+	return b.Logger
+}
+
+/*
+* 作り直しても引き継ぐ利用者のテーブルと列
+ */
+var zundaDbUserTables = []struct {
+	Name    string
+	Columns string
+}{
+	{Name: "WordListTable", Columns: "list_name, word"},
+	{Name: "ReplacementTable", Columns: "source, source_key, word_type, target"},
+}
+
+/*
+* zunda.dbの作成
+* 一時ファイルへ作成してから置き換えるため、失敗しても既存のzunda.dbは残る
+* 既存のzunda.dbの単語リスト、置換辞書は作成したzunda.dbへ引き継ぐ
+ */
+func (b *ZundaDbBuilder) Build(path string) error {
+	logger := b.getLogger()
+	logger.Debug("ZundaDbBuilder#Build()")
+
+	rows := [][]string{}
+	for _, fileName := range []string{IPADIC_VERB_FILE, IPADIC_ADJ_FILE} {
+		fileRows, err := b.readIpadicCsv(fileName)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, fileRows...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	os.Remove(tempPath)
+	db, err := sql.Open("sqlite3", tempPath)
+	if err != nil {
+		return err
+	}
+	// ATTACHは接続ごとのため、1つの接続で作成する
+	db.SetMaxOpenConns(1)
+	err = b.build(db, rows)
+	if err == nil {
+		err = b.copyUserTables(db, path)
+	}
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+func (b *ZundaDbBuilder) build(db *sql.DB, rows [][]string) error {
+	if _, _, err := migrateZundaDb(db, b.getLogger()); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := b.importConjugationTable(tx, rows); err != nil {
+		return err
+	}
+	if err := b.insertMetadata(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+/*
+* 既存のzunda.dbから利用者のテーブルを複製する
+* 既存のzunda.dbが無い場合、テーブルが無い(スキーマが古い)場合は何もしない
+ */
+func (b *ZundaDbBuilder) copyUserTables(db *sql.DB, existingPath string) error {
+	if _, err := os.Stat(existingPath); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := db.Exec("ATTACH DATABASE ? AS existing", existingPath); err != nil {
+		return err
+	}
+	defer db.Exec("DETACH DATABASE existing")
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range zundaDbUserTables {
+		var count int
+		err := tx.QueryRow("SELECT COUNT(*) FROM existing.sqlite_master WHERE type = 'table' AND name = ?", table.Name).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			continue
+		}
+		result, err := tx.Exec(fmt.Sprintf("INSERT OR IGNORE INTO main.%s(%s) SELECT %s FROM existing.%s ORDER BY rowid",
+			table.Name, table.Columns, table.Columns, table.Name))
+		if err != nil {
+			return err
+		}
+		if ce := b.getLogger().Check(zap.InfoLevel, "ZundaDbBuilder#copyUserTables()"); ce != nil {
+			copied, _ := result.RowsAffected()
+			ce.Write(zap.String("table", table.Name), zap.Int64("rows", copied))
+		}
+	}
+	return tx.Commit()
+}
+
+/*
+* 動詞・形容詞の活用テーブル
+* 読みだけが異なる同一表記の行は1行にまとめる
+ */
+func (b *ZundaDbBuilder) importConjugationTable(tx *sql.Tx, rows [][]string) error {
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO ConjugationTable(base_word, word_type, conjugation_type, conjugation_form, word)
+VALUES(?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if row[ipadicColumnConjugationForm] == MecabConjugationFormNone.String() {
+			continue
+		}
+		_, err := stmt.Exec(
+			row[ipadicColumnOriginalForm],
+			row[ipadicColumnWordType],
+			row[ipadicColumnConjugationType],
+			row[ipadicColumnConjugationForm],
+			row[ipadicColumnWord])
+		if err != nil {
+			return err
+		}
+	}
+	if ce := b.getLogger().Check(zap.InfoLevel, "ZundaDbBuilder#importConjugationTable()"); ce != nil {
+		ce.Write(zap.Int("rows", len(rows)))
+	}
+	return nil
+}
+
+func (b *ZundaDbBuilder) insertMetadata(tx *sql.Tx) error {
+	source, err := filepath.Abs(b.IpadicDir)
+	if err != nil {
+		return err
+	}
+	metadata := [][2]string{
+		{"source", source},
+		{"version", b.version()},
+	}
+	for _, entry := range metadata {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO MetadataTable(key, value) VALUES(?, ?)", entry[0], entry[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var ipadicVersionPattern = regexp.MustCompile(`AC_INIT\(\s*mecab-ipadic\s*,\s*([^\s)]+)\s*\)`)
+
+/*
+* 辞書のバージョン
+* 指定が無い場合はconfigure.inのAC_INIT、ディレクトリ名(mecab-ipadic-<version>)の順に推定する
+ */
+func (b *ZundaDbBuilder) version() string {
+	if b.Version != "" {
+		return b.Version
+	}
+	if configure, err := os.ReadFile(filepath.Join(b.IpadicDir, "configure.in")); err == nil {
+		if match := ipadicVersionPattern.FindSubmatch(configure); match != nil {
+			return string(match[1])
+		}
+	}
+	dirName := filepath.Base(filepath.Clean(b.IpadicDir))
+	if strings.HasPrefix(dirName, "mecab-ipadic-") {
+		return strings.TrimPrefix(dirName, "mecab-ipadic-")
+	}
+	return "unknown"
+}
+
+/*
+* IPADIC CSVの読み込み
+* 列数が足りない行はエラーとする
+ */
+func (b *ZundaDbBuilder) readIpadicCsv(fileName string) ([][]string, error) {
+	path := filepath.Join(b.IpadicDir, fileName)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader = bytes.NewReader(content)
+	switch b.Encoding {
+	case IpadicEncodingUtf8:
+	case IpadicEncodingEucJp:
+		reader = japanese.EUCJP.NewDecoder().Reader(reader)
+	case IpadicEncodingAuto, "":
+		if !utf8.Valid(content) {
+			reader = japanese.EUCJP.NewDecoder().Reader(reader)
+		}
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", b.Encoding)
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+	rows := [][]string{}
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(row) < ipadicColumnCount {
+			line, _ := csvReader.FieldPos(0)
+			return nil, fmt.Errorf("%s:%d: expect %d columns, got %d", path, line, ipadicColumnCount, len(row))
+		}
+		rows = append(rows, row)
+	}
+	if ce := b.getLogger().Check(zap.DebugLevel, "ZundaDbBuilder#readIpadicCsv()"); ce != nil {
+		ce.Write(zap.String("file", path), zap.Int("rows", len(rows)))
+	}
+	return rows, nil
+}
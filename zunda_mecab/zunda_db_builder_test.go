@@ -0,0 +1,159 @@
+package zunda_mecab
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestZundaDbBuilder(t *testing.T) {
+	tests := []struct {
+		name          string
+		ipadicDir     string
+		encoding      IpadicEncoding
+		version       string
+		expectVersion string
+	}{
+		{
+			name:          "UTF-8",
+			ipadicDir:     "testdata/ipadic",
+			expectVersion: "2.7.0-20070801",
+		},
+		{
+			name:          "EUC-JP自動判定",
+			ipadicDir:     "testdata/ipadic-eucjp",
+			expectVersion: "2.7.0-20070801",
+		},
+		{
+			name:          "EUC-JP指定+バージョン指定",
+			ipadicDir:     "testdata/ipadic-eucjp",
+			encoding:      IpadicEncodingEucJp,
+			version:       "test",
+			expectVersion: "test",
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "zunda.db")
+			builder := ZundaDbBuilder{
+				Logger:    getTestLogger(),
+				IpadicDir: testCase.ipadicDir,
+				Encoding:  testCase.encoding,
+				Version:   testCase.version,
+			}
+			if err := builder.Build(path); err != nil {
+				t.Fatalf("ZundaDbBuilder.Build() error = %v", err)
+			}
+
+			repository, err := NewZundaDbRepository(path, getTestLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer repository.Close()
+			expects := []ConjugationRow{
+				{BaseWord: "書く", WordType: MecabWordTypeVerb, ConjugationType: MecabConjugationTypeGodanKaIOnbin, ConjugationForm: MecabConjugationFormMizen, Word: "書か"},
+				{BaseWord: "書く", WordType: MecabWordTypeVerb, ConjugationType: MecabConjugationTypeGodanKaIOnbin, ConjugationForm: MecabConjugationFormRenyouTaSetsuzoku, Word: "書い"},
+				{BaseWord: "食べる", WordType: MecabWordTypeVerb, ConjugationType: MecabConjugationTypeIchidan, ConjugationForm: MecabConjugationFormMeireiRo, Word: "食べろ"},
+				{BaseWord: "来る", WordType: MecabWordTypeVerb, ConjugationType: MecabConjugationTypeKahenKuruKanji, ConjugationForm: MecabConjugationFormMizen, Word: "来"},
+				{BaseWord: "高い", WordType: MecabWordTypeAdjective, ConjugationType: MecabConjugationTypeAuo, ConjugationForm: MecabConjugationFormRenyouTaSetsuzoku, Word: "高かっ"},
+				{BaseWord: "高い", WordType: MecabWordTypeAdjective, ConjugationType: MecabConjugationTypeAuo, ConjugationForm: MecabConjugationFormKatei, Word: "高けれ"},
+			}
+			for _, expect := range expects {
+				actual, err := repository.SelectConjugation(expect.BaseWord, expect.ConjugationType, expect.ConjugationForm)
+				if err != nil {
+					t.Fatalf("SelectConjugation(%s, %s) error = %v", expect.BaseWord, expect.ConjugationForm, err)
+				}
+				if actual != expect {
+					t.Fatalf("SelectConjugation(%s, %s) = %v, expect %v", expect.BaseWord, expect.ConjugationForm, actual, expect)
+				}
+			}
+
+			db, err := sql.Open("sqlite3", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			var version string
+			if err := db.QueryRow("SELECT value FROM MetadataTable WHERE key = 'version'").Scan(&version); err != nil {
+				t.Fatal(err)
+			}
+			if version != testCase.expectVersion {
+				t.Fatalf("version = %v, expect %v", version, testCase.expectVersion)
+			}
+		})
+	}
+}
+
+func TestZundaDbBuilderInvalidCsv(t *testing.T) {
+	ipadicDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(ipadicDir, IPADIC_VERB_FILE), []byte("書か,1,1,1,動詞\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "zunda.db")
+	builder := ZundaDbBuilder{
+		IpadicDir: ipadicDir,
+	}
+	if err := builder.Build(path); err == nil {
+		t.Fatal("ZundaDbBuilder.Build() expect error")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("zunda.db should not be created: %v", err)
+	}
+}
+
+func TestZundaDbBuilderKeepUserTables(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zunda.db")
+	builder := ZundaDbBuilder{
+		Logger:    getTestLogger(),
+		IpadicDir: "testdata/ipadic",
+	}
+	if err := builder.Build(path); err != nil {
+		t.Fatalf("ZundaDbBuilder.Build() error = %v", err)
+	}
+	repository, err := NewWritableZundaDbRepository(path, getTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacements := []ReplacementRow{
+		{Source: "僕", Key: ReplacementKeyWord, WordType: MecabWordTypeNoun, Target: "ボク"},
+		{Source: "ずんだ餅", Key: ReplacementKeyOriginalForm, WordType: MecabWordTypeUnknown, Target: "ずんだもち"},
+	}
+	if err := repository.InsertReplacements(replacements); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.db.Exec("INSERT INTO WordListTable(list_name, word) VALUES('敬語', 'です')"); err != nil {
+		t.Fatal(err)
+	}
+	repository.Close()
+
+	// 作り直す
+	if err := builder.Build(path); err != nil {
+		t.Fatalf("ZundaDbBuilder.Build() error = %v", err)
+	}
+	repository, err = NewZundaDbRepository(path, getTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repository.Close()
+	actual, err := repository.SelectReplacements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, replacements) {
+		t.Fatalf("SelectReplacements() = %v, expect %v", actual, replacements)
+	}
+	words, err := repository.SelectWordList("敬語")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(words, []string{"です"}) {
+		t.Fatalf("SelectWordList() = %v, expect [です]", words)
+	}
+	if _, err := repository.SelectConjugation("書く", MecabConjugationTypeGodanKaIOnbin, MecabConjugationFormMizen); err != nil {
+		t.Fatalf("SelectConjugation() error = %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package zunda_mecab
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+/*
+* zunda.dbのスキーマ変更
+* migrations/<バージョン>_<説明>.sql をバージョン順に適用し、適用済みのバージョンをMetadataTableのschema_versionに記録する
+* 既存のテーブルがあっても適用できるよう、各SQLはIF NOT EXISTSで記述すること
+ */
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const schemaVersionKey = "schema_version"
+
+type zundaDbMigration struct {
+	Version int
+	Name    string
+	Sql     string
+}
+
+var zundaDbMigrations = loadZundaDbMigrations()
+
+func loadZundaDbMigrations() []zundaDbMigration {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	migrations := []zundaDbMigration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			panic(fmt.Sprintf("invalid migration file name: %s", name))
+		}
+		content, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			panic(err)
+		}
+		migrations = append(migrations, zundaDbMigration{
+			Version: version,
+			Name:    name,
+			Sql:     string(content),
+		})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			panic(fmt.Sprintf("migration version must be sequential: %s", migration.Name))
+		}
+	}
+	return migrations
+}
+
+/*
+* このバイナリが扱えるzunda.dbのスキーマバージョン
+ */
+func ZundaDbSchemaVersion() int {
+	return len(zundaDbMigrations)
+}
+
+/*
+* zunda.dbのスキーマがバイナリより新しい
+* errors.Is(err, ErrSchemaTooNew)で判定する
+ */
+var ErrSchemaTooNew = errors.New("zunda.db schema is newer than this zundafilter")
+
+/*
+* zunda.dbのスキーマが古い(dict migrateで更新する)
+* errors.Is(err, ErrSchemaOutdated)で判定する
+ */
+var ErrSchemaOutdated = errors.New("zunda.db schema is outdated")
+
+type SchemaVersionError struct {
+	Version   int
+	Supported int
+}
+
+func (e *SchemaVersionError) Error() string {
+	if e.Version < e.Supported {
+		return fmt.Sprintf("zunda.db schema version %d is outdated (supported version %d), run `zundafilter dict migrate`", e.Version, e.Supported)
+	}
+	return fmt.Sprintf("zunda.db schema version %d is newer than supported version %d, please update zundafilter", e.Version, e.Supported)
+}
+
+func (e *SchemaVersionError) Is(target error) bool {
+	if e.Version < e.Supported {
+		return target == ErrSchemaOutdated
+	}
+	return target == ErrSchemaTooNew
+}
+
+/*
+* スキーマが最新であることの確認(更新はしない)
+ */
+func checkSchemaVersion(db *sql.DB) error {
+	version, err := readSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version != ZundaDbSchemaVersion() {
+		return &SchemaVersionError{Version: version, Supported: ZundaDbSchemaVersion()}
+	}
+	return nil
+}
+
+/*
+* 適用済みのスキーマバージョン
+* バージョンの記録が無い(旧setup.shで作成した)zunda.dbは0とする
+ */
+func readSchemaVersion(db *sql.DB) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'MetadataTable'").Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	var value string
+	err = db.QueryRow("SELECT value FROM MetadataTable WHERE key = ?", schemaVersionKey).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %s", schemaVersionKey, value)
+	}
+	return version, nil
+}
+
+/*
+* 未適用のスキーマ変更を適用する
+* 1バージョンずつトランザクションで適用するため、途中で失敗しても直前のバージョンまでは残る
+ */
+func migrateZundaDb(db *sql.DB, logger *zap.Logger) (from int, to int, err error) {
+	from, err = readSchemaVersion(db)
+	if err != nil {
+		return 0, 0, err
+	}
+	if from > ZundaDbSchemaVersion() {
+		return from, from, &SchemaVersionError{Version: from, Supported: ZundaDbSchemaVersion()}
+	}
+	to = from
+	for _, migration := range zundaDbMigrations[from:] {
+		if err := applyZundaDbMigration(db, migration); err != nil {
+			return from, to, fmt.Errorf("%s: %w", migration.Name, err)
+		}
+		if ce := logger.Check(zap.InfoLevel, "migrateZundaDb()"); ce != nil {
+			ce.Write(zap.String("migration", migration.Name))
+		}
+		to = migration.Version
+	}
+	return from, to, nil
+}
+
+func applyZundaDbMigration(db *sql.DB, migration zundaDbMigration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(migration.Sql); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO MetadataTable(key, value) VALUES(?, ?)", schemaVersionKey, strconv.Itoa(migration.Version)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+/*
+* zunda.dbを最新のスキーマへ更新する
+* 更新前と更新後のスキーマバージョンを返す
+ */
+func MigrateZundaDb(path string, logger *zap.Logger) (from int, to int, err error) {
+	// sqlite3は存在しないファイルを新規作成するため事前に確認する
+	if _, err := os.Stat(path); err != nil {
+		return 0, 0, err
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer db.Close()
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return migrateZundaDb(db, logger)
+}
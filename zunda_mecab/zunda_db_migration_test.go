@@ -0,0 +1,169 @@
+package zunda_mecab
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMigrateZundaDb(t *testing.T) {
+	tests := []struct {
+		name       string
+		statements []string
+		expectFrom int
+		expectWord string
+	}{
+		{
+			name:       "新規作成",
+			expectFrom: 0,
+		},
+		{
+			name: "旧setup.sh(動詞の未然形変換テーブル)",
+			statements: []string{
+				"CREATE TABLE ConvertVerbConjugationTable(base_word TEXT, mizen TEXT)",
+				"INSERT INTO ConvertVerbConjugationTable VALUES('書く', '書か')",
+			},
+			expectFrom: 0,
+			expectWord: "書か",
+		},
+		{
+			name: "バージョン記録なし",
+			statements: []string{
+				"CREATE TABLE ConjugationTable(base_word TEXT, word_type TEXT, conjugation_type TEXT, conjugation_form TEXT, word TEXT)",
+				"CREATE TABLE MetadataTable(key TEXT PRIMARY KEY, value TEXT)",
+				"INSERT INTO ConjugationTable VALUES('書く', '動詞', '五段・カ行イ音便', '未然形', '書か')",
+			},
+			expectFrom: 0,
+			expectWord: "書か",
+		},
+		{
+			name: "一部適用済み",
+			statements: []string{
+				"CREATE TABLE ConjugationTable(base_word TEXT, word_type TEXT, conjugation_type TEXT, conjugation_form TEXT, word TEXT)",
+				"CREATE TABLE MetadataTable(key TEXT PRIMARY KEY, value TEXT)",
+				"INSERT INTO MetadataTable VALUES('schema_version', '1')",
+			},
+			expectFrom: 1,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "zunda.db")
+			execTestStatements(t, path, testCase.statements)
+
+			from, to, err := MigrateZundaDb(path, getTestLogger())
+			if err != nil {
+				t.Fatalf("MigrateZundaDb() error = %v", err)
+			}
+			if from != testCase.expectFrom || to != ZundaDbSchemaVersion() {
+				t.Fatalf("MigrateZundaDb() = %d, %d, expect %d, %d", from, to, testCase.expectFrom, ZundaDbSchemaVersion())
+			}
+			// 2回目は何もしない
+			from, to, err = MigrateZundaDb(path, getTestLogger())
+			if err != nil || from != to {
+				t.Fatalf("MigrateZundaDb() = %d, %d, %v, expect up to date", from, to, err)
+			}
+
+			repository, err := NewZundaDbRepository(path, getTestLogger())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer repository.Close()
+			if testCase.expectWord != "" {
+				actual, err := repository.SelectConjugation("書く", MecabConjugationTypeGodanKaIOnbin, MecabConjugationFormMizen)
+				if err != nil {
+					t.Fatalf("SelectConjugation() error = %v", err)
+				}
+				if actual.Word != testCase.expectWord {
+					t.Fatalf("SelectConjugation() = %v, expect %v", actual.Word, testCase.expectWord)
+				}
+			}
+			if _, err := repository.SelectReplacements(); err != nil {
+				t.Fatalf("SelectReplacements() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateZundaDbTooNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zunda.db")
+	execTestStatements(t, path, []string{
+		"CREATE TABLE MetadataTable(key TEXT PRIMARY KEY, value TEXT)",
+		"INSERT INTO MetadataTable VALUES('schema_version', '9999')",
+	})
+
+	if _, _, err := MigrateZundaDb(path, getTestLogger()); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("MigrateZundaDb() error = %v, expect %v", err, ErrSchemaTooNew)
+	}
+	if _, err := NewZundaDbRepository(path, getTestLogger()); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("NewZundaDbRepository() error = %v, expect %v", err, ErrSchemaTooNew)
+	}
+}
+
+func TestNewZundaDbRepositoryOutdated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zunda.db")
+	execTestStatements(t, path, []string{
+		"CREATE TABLE ConjugationTable(base_word TEXT, word_type TEXT, conjugation_type TEXT, conjugation_form TEXT, word TEXT)",
+		"CREATE TABLE MetadataTable(key TEXT PRIMARY KEY, value TEXT)",
+		"INSERT INTO MetadataTable VALUES('schema_version', '1')",
+	})
+
+	for _, open := range []func(string, *zap.Logger) (*ZundaDbRepository, error){NewZundaDbRepository, NewWritableZundaDbRepository} {
+		if _, err := open(path, getTestLogger()); !errors.Is(err, ErrSchemaOutdated) {
+			t.Fatalf("NewZundaDbRepository() error = %v, expect %v", err, ErrSchemaOutdated)
+		}
+	}
+	// 開く際にスキーマを更新しない
+	from, _, err := MigrateZundaDb(path, getTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 1 {
+		t.Fatalf("MigrateZundaDb() from = %d, expect 1", from)
+	}
+	repository, err := NewZundaDbRepository(path, getTestLogger())
+	if err != nil {
+		t.Fatalf("NewZundaDbRepository() error = %v", err)
+	}
+	repository.Close()
+}
+
+func TestNewZundaDbRepositoryReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zunda #1.db")
+	execTestStatements(t, path, nil)
+	if _, _, err := MigrateZundaDb(path, getTestLogger()); err != nil {
+		t.Fatal(err)
+	}
+
+	repository, err := NewZundaDbRepository(path, getTestLogger())
+	if err != nil {
+		t.Fatalf("NewZundaDbRepository() error = %v", err)
+	}
+	defer repository.Close()
+	err = repository.InsertReplacements([]ReplacementRow{{Source: "僕", Key: ReplacementKeyWord, Target: "ボク"}})
+	if err == nil {
+		t.Fatal("InsertReplacements() expect error on read-only repository")
+	}
+}
+
+func execTestStatements(t *testing.T, path string, statements []string) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// sql.Openだけではファイルが作成されないため接続しておく
+	if err := db.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -1,57 +1,339 @@
 package zunda_mecab
 
 import (
-	"os"
-	"fmt"
-	"path/filepath"
-	"go.uber.org/zap"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"zundafilter/resource"
+
 	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
 )
 
 const (
-	DB_NAME = "data/zunda.db"
+	DB_NAME = "zunda.db"
+	DB_ENV  = "ZUNDAFILTER_DB"
 )
 
+/*
+* 該当行が存在しない
+* errors.Is(err, ErrNotFound)で判定する
+ */
+var ErrNotFound = errors.New("not found")
+
+type NotFoundError struct {
+	Table string
+	Key   string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s: %s is not found", e.Table, e.Key)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+/*
+* zunda.dbへのアクセス
+* 接続とプリペアドステートメントを保持し、複数のgoroutineから同時に利用できる
+* スキーマの更新は行わず、古ければエラー(ErrSchemaOutdated)、バイナリより新しければエラー(ErrSchemaTooNew)とする
+* 置換辞書は開く際に読み込み、登録、削除した際に読み込み直す
+* 利用後はCloseすること
+ */
 type ZundaDbRepository struct {
+	Logger *zap.Logger // nilの場合はログを出力しない
+
+	db                     *sql.DB
+	selectConjugationStmt  *sql.Stmt
+	selectWordListStmt     *sql.Stmt
+	selectReplacementsStmt *sql.Stmt
+
+	replacementsMutex sync.RWMutex
+	replacements      []ReplacementRow
+}
+
+/*
+* 活用テーブルの1行
+* ex) 書く, 動詞, 五段・カ行イ音便, 連用タ接続 -> 書い
+ */
+type ConjugationRow struct {
+	BaseWord        string
+	WordType        MecabWordType
+	ConjugationType MecabConjugationType
+	ConjugationForm MecabConjugationForm
+	Word            string
+}
+
+/*
+* 読み込むzunda.dbのパス
+* flagValue、環境変数ZUNDAFILTER_DB、XDG_DATA_HOME等、実行ファイルの配置ディレクトリの順に探す
+ */
+func ResolveZundaDbPath(flagValue string) (string, error) {
+	return resource.Resolve(resource.KindData, DB_NAME, flagValue, DB_ENV)
+}
+
+/*
+* 作成するzunda.dbのパス
+* 既存のzunda.dbが見つかればそのパス、無ければ$XDG_DATA_HOME/zundafilter/zunda.db
+ */
+func ZundaDbOutputPath(flagValue string) (string, error) {
+	path, err := ResolveZundaDbPath(flagValue)
+	if errors.Is(err, resource.ErrNotFound) {
+		return resource.UserPath(resource.KindData, DB_NAME)
+	}
+	return path, err
 }
-type ConvertVerbConjugationRow struct {
-	BaseWord string
-	Mizen    string
+
+/*
+* 変換用に読み込み専用で開く
+* 書き込めないzunda.dbも開ける
+ */
+func NewZundaDbRepository(path string, logger *zap.Logger) (*ZundaDbRepository, error) {
+	return openZundaDbRepository(path, logger, false)
 }
 
-func (z ZundaDbRepository) SelectConvertVerbConjugationTable(baseWord string) (ConvertVerbConjugationRow, error) {
-	logger, _ := zap.NewDevelopment()
-	defer logger.Sync()
-	sugar := logger.Sugar()
-	sugar.Debug("ZundaDbRepository#SelectConvertVerbConjugationTable()")
+/*
+* 置換辞書の登録、削除用に書き込み可能で開く
+ */
+func NewWritableZundaDbRepository(path string, logger *zap.Logger) (*ZundaDbRepository, error) {
+	return openZundaDbRepository(path, logger, true)
+}
 
-	path, err := os.Executable()
+func openZundaDbRepository(path string, logger *zap.Logger, writable bool) (*ZundaDbRepository, error) {
+	// sqlite3は存在しないファイルを新規作成するため事前に確認する
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	dataSource := path
+	if !writable {
+		dataSource = "file:" + (&url.URL{Path: path}).EscapedPath() + "?mode=ro"
+	}
+	db, err := sql.Open("sqlite3", dataSource)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	binDir := filepath.Dir(path)
-	db, err := sql.Open("sqlite3", fmt.Sprintf("%s/%s", binDir, DB_NAME))
+	z := &ZundaDbRepository{
+		Logger: logger,
+		db:     db,
+	}
+	if err := checkSchemaVersion(db); err != nil {
+		z.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	// 活用型が"*"の場合は活用型を問わない(旧setup.shから引き継いだ行も活用型が"*")
+	// 同一条件に複数の表記がある場合は辞書の先頭を優先する
+	z.selectConjugationStmt, err = db.Prepare(`SELECT word_type, conjugation_type, word FROM ConjugationTable
+WHERE base_word = ? AND conjugation_form = ? AND (? = '*' OR conjugation_type = ? OR conjugation_type = '*')
+ORDER BY rowid LIMIT 1`)
 	if err != nil {
-		return ConvertVerbConjugationRow{}, err
+		z.Close()
+		return nil, err
 	}
-	defer db.Close()
+	z.selectWordListStmt, err = db.Prepare("SELECT word FROM WordListTable WHERE list_name = ?")
+	if err != nil {
+		z.Close()
+		return nil, err
+	}
+	z.selectReplacementsStmt, err = db.Prepare("SELECT source, source_key, word_type, target FROM ReplacementTable ORDER BY rowid")
+	if err != nil {
+		z.Close()
+		return nil, err
+	}
+	if err := z.loadReplacements(); err != nil {
+		z.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return z, nil
+}
+
+func (z *ZundaDbRepository) Close() error {
+	for _, stmt := range []*sql.Stmt{z.selectConjugationStmt, z.selectWordListStmt, z.selectReplacementsStmt} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+	return z.db.Close()
+}
+
+func (z *ZundaDbRepository) getLogger() *zap.Logger {
+	if z.Logger == nil {
+		return zap.NewNop()
+	}
+	return z.Logger
+}
+
+/*
+* 原形baseWordの活用形conjugationFormを取得する
+* conjugationTypeがMecabConjugationTypeNoneの場合は活用型を問わない
+ */
+func (z *ZundaDbRepository) SelectConjugation(baseWord string, conjugationType MecabConjugationType, conjugationForm MecabConjugationForm) (ConjugationRow, error) {
+	logger := z.getLogger()
+	logger.Debug("ZundaDbRepository#SelectConjugation()")
 
-	stmt, err := db.Prepare("SELECT mizen FROM ConvertVerbConjugationTable WHERE base_word = ?")
+	var wordType, foundConjugationType, word string
+	err := z.selectConjugationStmt.QueryRow(baseWord, conjugationForm.String(), conjugationType.String(), conjugationType.String()).Scan(&wordType, &foundConjugationType, &word)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ConjugationRow{}, &NotFoundError{
+			Table: "ConjugationTable",
+			Key:   fmt.Sprintf("%s(%s, %s)", baseWord, conjugationType, conjugationForm),
+		}
+	}
 	if err != nil {
-		return ConvertVerbConjugationRow{}, err
+		return ConjugationRow{}, err
 	}
-	defer stmt.Close()
 
-	var mizen string
-	err = stmt.QueryRow(baseWord).Scan(&mizen)
+	if ce := logger.Check(zap.DebugLevel, "ZundaDbRepository#SelectConjugation() - return"); ce != nil {
+		ce.Write(zap.String("baseWord", baseWord), zap.Stringer("conjugationForm", conjugationForm), zap.String("word", word))
+	}
+	return ConjugationRow{
+		BaseWord:        baseWord,
+		WordType:        parseMecabWordType(wordType),
+		ConjugationType: parseMecabConjugationType(foundConjugationType),
+		ConjugationForm: conjugationForm,
+		Word:            word,
+	}, nil
+}
+
+/*
+* 単語リストの取得
+* 条件の単語リスト(CheckWordList, CheckOriginalFormList)から参照される
+ */
+func (z *ZundaDbRepository) SelectWordList(listName string) ([]string, error) {
+	logger := z.getLogger()
+	logger.Debug("ZundaDbRepository#SelectWordList()")
+
+	rows, err := z.selectWordListStmt.Query(listName)
 	if err != nil {
-		return ConvertVerbConjugationRow{}, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	sugar.Debugf("ZundaDbRepository#SelectConvertVerbConjugationTable() - return (%v, %v)", baseWord, mizen)
-	return ConvertVerbConjugationRow{
-		BaseWord: baseWord,
-		Mizen:    mizen,
-	}, err
+	words := []string{}
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if ce := logger.Check(zap.DebugLevel, "ZundaDbRepository#SelectWordList() - return"); ce != nil {
+		ce.Write(zap.String("list", listName), zap.Int("words", len(words)))
+	}
+	return words, nil
+}
+
+/*
+* 置換辞書の取得
+* 登録順に返す(読み込み済みの置換辞書を返すため、返り値を変更しないこと)
+ */
+func (z *ZundaDbRepository) SelectReplacements() ([]ReplacementRow, error) {
+	z.getLogger().Debug("ZundaDbRepository#SelectReplacements()")
+
+	z.replacementsMutex.RLock()
+	defer z.replacementsMutex.RUnlock()
+	return z.replacements[:len(z.replacements):len(z.replacements)], nil
+}
+
+/*
+* 置換辞書の読み込み
+ */
+func (z *ZundaDbRepository) loadReplacements() error {
+	replacements, err := z.queryReplacements()
+	if err != nil {
+		return err
+	}
+	z.replacementsMutex.Lock()
+	defer z.replacementsMutex.Unlock()
+	z.replacements = replacements
+	return nil
+}
+
+func (z *ZundaDbRepository) queryReplacements() ([]ReplacementRow, error) {
+	rows, err := z.selectReplacementsStmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	replacements := []ReplacementRow{}
+	for rows.Next() {
+		var source, sourceKey, wordType, target string
+		if err := rows.Scan(&source, &sourceKey, &wordType, &target); err != nil {
+			return nil, err
+		}
+		replacement := ReplacementRow{
+			Source: source,
+			Target: target,
+		}
+		if replacement.Key, err = ParseReplacementKey(sourceKey); err != nil {
+			return nil, err
+		}
+		if replacement.WordType, err = ParseReplacementWordType(wordType); err != nil {
+			return nil, err
+		}
+		replacements = append(replacements, replacement)
+	}
+	return replacements, rows.Err()
+}
+
+/*
+* 置換辞書への登録
+* 置換元・照合対象・品詞が同じ行は置換先を上書きする
+ */
+func (z *ZundaDbRepository) InsertReplacements(replacements []ReplacementRow) error {
+	z.getLogger().Debug("ZundaDbRepository#InsertReplacements()")
+
+	tx, err := z.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`INSERT INTO ReplacementTable(source, source_key, word_type, target) VALUES(?, ?, ?, ?)
+ON CONFLICT(source, source_key, word_type) DO UPDATE SET target = excluded.target`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, replacement := range replacements {
+		_, err := stmt.Exec(replacement.Source, replacement.Key.String(), replacementWordTypeString(replacement.WordType), replacement.Target)
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return z.loadReplacements()
+}
+
+/*
+* 置換辞書からの削除
+ */
+func (z *ZundaDbRepository) DeleteReplacement(source string, key ReplacementKey, wordType MecabWordType) error {
+	z.getLogger().Debug("ZundaDbRepository#DeleteReplacement()")
+
+	result, err := z.db.Exec("DELETE FROM ReplacementTable WHERE source = ? AND source_key = ? AND word_type = ?",
+		source, key.String(), replacementWordTypeString(wordType))
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return &NotFoundError{
+			Table: "ReplacementTable",
+			Key:   source,
+		}
+	}
+	return z.loadReplacements()
 }
@@ -0,0 +1,233 @@
+package zunda_mecab
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func createTestZundaDb(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "zunda.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	statements := []string{
+		"CREATE TABLE ConjugationTable(base_word TEXT, word_type TEXT, conjugation_type TEXT, conjugation_form TEXT, word TEXT)",
+		"CREATE TABLE WordListTable(list_name TEXT, word TEXT)",
+		"CREATE TABLE ReplacementTable(source TEXT, source_key TEXT, word_type TEXT, target TEXT, UNIQUE(source, source_key, word_type))",
+		"INSERT INTO ConjugationTable VALUES('書く', '動詞', '五段・カ行イ音便', '未然形', '書か'), ('書く', '動詞', '五段・カ行イ音便', '連用タ接続', '書い'), ('来る', '動詞', 'カ変・来ル', '未然形', '来')",
+		"INSERT INTO WordListTable VALUES('pronoun', '私'), ('pronoun', '俺')",
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := MigrateZundaDb(path, getTestLogger()); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSelectConjugation(t *testing.T) {
+	repository, err := NewZundaDbRepository(createTestZundaDb(t), getTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repository.Close()
+
+	tests := []struct {
+		name            string
+		baseWord        string
+		conjugationType MecabConjugationType
+		conjugationForm MecabConjugationForm
+		expect          ConjugationRow
+		expectError     error
+	}{
+		{
+			name:            "未然形",
+			baseWord:        "書く",
+			conjugationType: MecabConjugationTypeGodanKaIOnbin,
+			conjugationForm: MecabConjugationFormMizen,
+			expect: ConjugationRow{
+				BaseWord:        "書く",
+				WordType:        MecabWordTypeVerb,
+				ConjugationType: MecabConjugationTypeGodanKaIOnbin,
+				ConjugationForm: MecabConjugationFormMizen,
+				Word:            "書か",
+			},
+		},
+		{
+			name:            "活用型指定なし",
+			baseWord:        "書く",
+			conjugationType: MecabConjugationTypeNone,
+			conjugationForm: MecabConjugationFormRenyouTaSetsuzoku,
+			expect: ConjugationRow{
+				BaseWord:        "書く",
+				WordType:        MecabWordTypeVerb,
+				ConjugationType: MecabConjugationTypeGodanKaIOnbin,
+				ConjugationForm: MecabConjugationFormRenyouTaSetsuzoku,
+				Word:            "書い",
+			},
+		},
+		{
+			name:            "活用型違い",
+			baseWord:        "書く",
+			conjugationType: MecabConjugationTypeIchidan,
+			conjugationForm: MecabConjugationFormMizen,
+			expect:          ConjugationRow{},
+			expectError:     ErrNotFound,
+		},
+		{
+			name:            "該当なし",
+			baseWord:        "走る",
+			conjugationType: MecabConjugationTypeGodanRa,
+			conjugationForm: MecabConjugationFormMizen,
+			expect:          ConjugationRow{},
+			expectError:     ErrNotFound,
+		},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := repository.SelectConjugation(testCase.baseWord, testCase.conjugationType, testCase.conjugationForm)
+			if !errors.Is(err, testCase.expectError) {
+				t.Fatalf("SelectConjugation() error = %v, expect %v", err, testCase.expectError)
+			}
+			if actual != testCase.expect {
+				t.Fatalf("SelectConjugation() = %v, expect %v", actual, testCase.expect)
+			}
+		})
+	}
+}
+
+func TestSelectWordListConcurrent(t *testing.T) {
+	repository, err := NewZundaDbRepository(createTestZundaDb(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repository.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			words, err := repository.SelectWordList("pronoun")
+			if err == nil && len(words) != 2 {
+				err = errors.New("unexpected word count")
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestNewZundaDbRepositoryMissingFile(t *testing.T) {
+	_, err := NewZundaDbRepository(filepath.Join(t.TempDir(), "missing.db"), nil)
+	if err == nil {
+		t.Fatal("NewZundaDbRepository() expect error")
+	}
+}
+
+func TestReplacements(t *testing.T) {
+	repository, err := NewWritableZundaDbRepository(createTestZundaDb(t), getTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repository.Close()
+
+	err = repository.InsertReplacements([]ReplacementRow{
+		{Source: "僕", Key: ReplacementKeyWord, WordType: MecabWordTypeNoun, Target: "ボク"},
+		{Source: "ずんだ餅", Key: ReplacementKeyWord, Target: "ずんだもち"},
+		{Source: "僕", Key: ReplacementKeyWord, WordType: MecabWordTypeNoun, Target: "ぼく"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []ReplacementRow{
+		{Source: "僕", Key: ReplacementKeyWord, WordType: MecabWordTypeNoun, Target: "ぼく"},
+		{Source: "ずんだ餅", Key: ReplacementKeyWord, WordType: MecabWordTypeUnknown, Target: "ずんだもち"},
+	}
+	actual, err := repository.SelectReplacements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Fatalf("SelectReplacements() = %v, expect %v", actual, expect)
+	}
+
+	if err := repository.DeleteReplacement("僕", ReplacementKeyWord, MecabWordTypeNoun); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.DeleteReplacement("僕", ReplacementKeyWord, MecabWordTypeNoun); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("DeleteReplacement() error = %v, expect %v", err, ErrNotFound)
+	}
+	actual, err = repository.SelectReplacements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expect[1:]) {
+		t.Fatalf("SelectReplacements() = %v, expect %v", actual, expect[1:])
+	}
+}
+
+func TestReplacementsLoadedOnOpen(t *testing.T) {
+	path := createTestZundaDb(t)
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO ReplacementTable VALUES('僕', 'word', '名詞', 'ボク')"); err != nil {
+		t.Fatal(err)
+	}
+	repository, err := NewWritableZundaDbRepository(path, getTestLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repository.Close()
+
+	// 開いた後にリポジトリを介さず変更した行は、置換辞書を変更するまで読み込まない
+	if _, err := db.Exec("INSERT INTO ReplacementTable VALUES('俺', 'word', '*', 'おれ')"); err != nil {
+		t.Fatal(err)
+	}
+	expect := []ReplacementRow{
+		{Source: "僕", Key: ReplacementKeyWord, WordType: MecabWordTypeNoun, Target: "ボク"},
+	}
+	actual, err := repository.SelectReplacements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Fatalf("SelectReplacements() = %v, expect %v", actual, expect)
+	}
+
+	if err := repository.InsertReplacements([]ReplacementRow{{Source: "ずんだ餅", Key: ReplacementKeyWord, Target: "ずんだもち"}}); err != nil {
+		t.Fatal(err)
+	}
+	expect = append(expect,
+		ReplacementRow{Source: "俺", Key: ReplacementKeyWord, WordType: MecabWordTypeUnknown, Target: "おれ"},
+		ReplacementRow{Source: "ずんだ餅", Key: ReplacementKeyWord, WordType: MecabWordTypeUnknown, Target: "ずんだもち"},
+	)
+	actual, err = repository.SelectReplacements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expect) {
+		t.Fatalf("SelectReplacements() = %v, expect %v", actual, expect)
+	}
+}
@@ -1,3 +1,7 @@
+/*
+* テキストをずんだもんの口調へ変換するライブラリ
+* zundafilter.New(opts...)で作成し、Convert、ConvertBatchで変換する
+ */
 package zundafilter
 
 var (